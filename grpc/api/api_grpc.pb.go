@@ -11,6 +11,7 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -32,6 +33,13 @@ type StudentsServiceClient interface {
 	// Bidirectional streaming
 	// Imports students and returns them with generated IDs
 	ImportStudentsV2(ctx context.Context, opts ...grpc.CallOption) (StudentsService_ImportStudentsV2Client, error)
+	// Unary
+	CreateStudent(ctx context.Context, in *CreateStudentRequest, opts ...grpc.CallOption) (*Student, error)
+	// Unary
+	// Partially updates a student according to the update mask
+	UpdateStudent(ctx context.Context, in *UpdateStudentRequest, opts ...grpc.CallOption) (*Student, error)
+	// Unary
+	DeleteStudent(ctx context.Context, in *DeleteStudentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type studentsServiceClient struct {
@@ -148,6 +156,33 @@ func (x *studentsServiceImportStudentsV2Client) Recv() (*ImportStudentsV2Respons
 	return m, nil
 }
 
+func (c *studentsServiceClient) CreateStudent(ctx context.Context, in *CreateStudentRequest, opts ...grpc.CallOption) (*Student, error) {
+	out := new(Student)
+	err := c.cc.Invoke(ctx, "/StudentsService/CreateStudent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studentsServiceClient) UpdateStudent(ctx context.Context, in *UpdateStudentRequest, opts ...grpc.CallOption) (*Student, error) {
+	out := new(Student)
+	err := c.cc.Invoke(ctx, "/StudentsService/UpdateStudent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *studentsServiceClient) DeleteStudent(ctx context.Context, in *DeleteStudentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/StudentsService/DeleteStudent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StudentsServiceServer is the server API for StudentsService service.
 // All implementations must embed UnimplementedStudentsServiceServer
 // for forward compatibility
@@ -162,6 +197,13 @@ type StudentsServiceServer interface {
 	// Bidirectional streaming
 	// Imports students and returns them with generated IDs
 	ImportStudentsV2(StudentsService_ImportStudentsV2Server) error
+	// Unary
+	CreateStudent(context.Context, *CreateStudentRequest) (*Student, error)
+	// Unary
+	// Partially updates a student according to the update mask
+	UpdateStudent(context.Context, *UpdateStudentRequest) (*Student, error)
+	// Unary
+	DeleteStudent(context.Context, *DeleteStudentRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedStudentsServiceServer()
 }
 
@@ -181,6 +223,15 @@ func (UnimplementedStudentsServiceServer) ImportStudents(StudentsService_ImportS
 func (UnimplementedStudentsServiceServer) ImportStudentsV2(StudentsService_ImportStudentsV2Server) error {
 	return status.Errorf(codes.Unimplemented, "method ImportStudentsV2 not implemented")
 }
+func (UnimplementedStudentsServiceServer) CreateStudent(context.Context, *CreateStudentRequest) (*Student, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateStudent not implemented")
+}
+func (UnimplementedStudentsServiceServer) UpdateStudent(context.Context, *UpdateStudentRequest) (*Student, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateStudent not implemented")
+}
+func (UnimplementedStudentsServiceServer) DeleteStudent(context.Context, *DeleteStudentRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteStudent not implemented")
+}
 func (UnimplementedStudentsServiceServer) mustEmbedUnimplementedStudentsServiceServer() {}
 
 // UnsafeStudentsServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -285,6 +336,60 @@ func (x *studentsServiceImportStudentsV2Server) Recv() (*ImportStudentsV2Request
 	return m, nil
 }
 
+func _StudentsService_CreateStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateStudentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudentsServiceServer).CreateStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/StudentsService/CreateStudent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudentsServiceServer).CreateStudent(ctx, req.(*CreateStudentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StudentsService_UpdateStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateStudentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudentsServiceServer).UpdateStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/StudentsService/UpdateStudent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudentsServiceServer).UpdateStudent(ctx, req.(*UpdateStudentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StudentsService_DeleteStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteStudentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StudentsServiceServer).DeleteStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/StudentsService/DeleteStudent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StudentsServiceServer).DeleteStudent(ctx, req.(*DeleteStudentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // StudentsService_ServiceDesc is the grpc.ServiceDesc for StudentsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -296,6 +401,18 @@ var StudentsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetStudentById",
 			Handler:    _StudentsService_GetStudentById_Handler,
 		},
+		{
+			MethodName: "CreateStudent",
+			Handler:    _StudentsService_CreateStudent_Handler,
+		},
+		{
+			MethodName: "UpdateStudent",
+			Handler:    _StudentsService_UpdateStudent_Handler,
+		},
+		{
+			MethodName: "DeleteStudent",
+			Handler:    _StudentsService_DeleteStudent_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -317,3 +434,415 @@ var StudentsService_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "api/api.proto",
 }
+
+// CoursesServiceClient is the client API for CoursesService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CoursesServiceClient interface {
+	GetCourse(ctx context.Context, in *GetCourseRequest, opts ...grpc.CallOption) (*Course, error)
+	ListCourses(ctx context.Context, in *ListCoursesRequest, opts ...grpc.CallOption) (*ListCoursesResponse, error)
+	CreateCourse(ctx context.Context, in *CreateCourseRequest, opts ...grpc.CallOption) (*Course, error)
+	// Partially updates a course according to the update mask
+	UpdateCourse(ctx context.Context, in *UpdateCourseRequest, opts ...grpc.CallOption) (*Course, error)
+	DeleteCourse(ctx context.Context, in *DeleteCourseRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Enrolls a student in a course, fails if the course is full
+	Enroll(ctx context.Context, in *EnrollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Unenroll(ctx context.Context, in *UnenrollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Lists the courses a student is enrolled in
+	ListStudentCourses(ctx context.Context, in *ListStudentCoursesRequest, opts ...grpc.CallOption) (*ListStudentCoursesResponse, error)
+	// Server-side streaming
+	// Lists the students enrolled in a course
+	GetCourseStudents(ctx context.Context, in *GetCourseStudentsRequest, opts ...grpc.CallOption) (CoursesService_GetCourseStudentsClient, error)
+}
+
+type coursesServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoursesServiceClient(cc grpc.ClientConnInterface) CoursesServiceClient {
+	return &coursesServiceClient{cc}
+}
+
+func (c *coursesServiceClient) GetCourse(ctx context.Context, in *GetCourseRequest, opts ...grpc.CallOption) (*Course, error) {
+	out := new(Course)
+	err := c.cc.Invoke(ctx, "/CoursesService/GetCourse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) ListCourses(ctx context.Context, in *ListCoursesRequest, opts ...grpc.CallOption) (*ListCoursesResponse, error) {
+	out := new(ListCoursesResponse)
+	err := c.cc.Invoke(ctx, "/CoursesService/ListCourses", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) CreateCourse(ctx context.Context, in *CreateCourseRequest, opts ...grpc.CallOption) (*Course, error) {
+	out := new(Course)
+	err := c.cc.Invoke(ctx, "/CoursesService/CreateCourse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) UpdateCourse(ctx context.Context, in *UpdateCourseRequest, opts ...grpc.CallOption) (*Course, error) {
+	out := new(Course)
+	err := c.cc.Invoke(ctx, "/CoursesService/UpdateCourse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) DeleteCourse(ctx context.Context, in *DeleteCourseRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/CoursesService/DeleteCourse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) Enroll(ctx context.Context, in *EnrollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/CoursesService/Enroll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) Unenroll(ctx context.Context, in *UnenrollRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/CoursesService/Unenroll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) ListStudentCourses(ctx context.Context, in *ListStudentCoursesRequest, opts ...grpc.CallOption) (*ListStudentCoursesResponse, error) {
+	out := new(ListStudentCoursesResponse)
+	err := c.cc.Invoke(ctx, "/CoursesService/ListStudentCourses", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coursesServiceClient) GetCourseStudents(ctx context.Context, in *GetCourseStudentsRequest, opts ...grpc.CallOption) (CoursesService_GetCourseStudentsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CoursesService_ServiceDesc.Streams[0], "/CoursesService/GetCourseStudents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &coursesServiceGetCourseStudentsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CoursesService_GetCourseStudentsClient interface {
+	Recv() (*GetStudentsResponse, error)
+	grpc.ClientStream
+}
+
+type coursesServiceGetCourseStudentsClient struct {
+	grpc.ClientStream
+}
+
+func (x *coursesServiceGetCourseStudentsClient) Recv() (*GetStudentsResponse, error) {
+	m := new(GetStudentsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CoursesServiceServer is the server API for CoursesService service.
+// All implementations must embed UnimplementedCoursesServiceServer
+// for forward compatibility
+type CoursesServiceServer interface {
+	GetCourse(context.Context, *GetCourseRequest) (*Course, error)
+	ListCourses(context.Context, *ListCoursesRequest) (*ListCoursesResponse, error)
+	CreateCourse(context.Context, *CreateCourseRequest) (*Course, error)
+	// Partially updates a course according to the update mask
+	UpdateCourse(context.Context, *UpdateCourseRequest) (*Course, error)
+	DeleteCourse(context.Context, *DeleteCourseRequest) (*emptypb.Empty, error)
+	// Enrolls a student in a course, fails if the course is full
+	Enroll(context.Context, *EnrollRequest) (*emptypb.Empty, error)
+	Unenroll(context.Context, *UnenrollRequest) (*emptypb.Empty, error)
+	// Lists the courses a student is enrolled in
+	ListStudentCourses(context.Context, *ListStudentCoursesRequest) (*ListStudentCoursesResponse, error)
+	// Server-side streaming
+	// Lists the students enrolled in a course
+	GetCourseStudents(*GetCourseStudentsRequest, CoursesService_GetCourseStudentsServer) error
+	mustEmbedUnimplementedCoursesServiceServer()
+}
+
+// UnimplementedCoursesServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCoursesServiceServer struct {
+}
+
+func (UnimplementedCoursesServiceServer) GetCourse(context.Context, *GetCourseRequest) (*Course, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCourse not implemented")
+}
+func (UnimplementedCoursesServiceServer) ListCourses(context.Context, *ListCoursesRequest) (*ListCoursesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCourses not implemented")
+}
+func (UnimplementedCoursesServiceServer) CreateCourse(context.Context, *CreateCourseRequest) (*Course, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCourse not implemented")
+}
+func (UnimplementedCoursesServiceServer) UpdateCourse(context.Context, *UpdateCourseRequest) (*Course, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCourse not implemented")
+}
+func (UnimplementedCoursesServiceServer) DeleteCourse(context.Context, *DeleteCourseRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCourse not implemented")
+}
+func (UnimplementedCoursesServiceServer) Enroll(context.Context, *EnrollRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Enroll not implemented")
+}
+func (UnimplementedCoursesServiceServer) Unenroll(context.Context, *UnenrollRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unenroll not implemented")
+}
+func (UnimplementedCoursesServiceServer) ListStudentCourses(context.Context, *ListStudentCoursesRequest) (*ListStudentCoursesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStudentCourses not implemented")
+}
+func (UnimplementedCoursesServiceServer) GetCourseStudents(*GetCourseStudentsRequest, CoursesService_GetCourseStudentsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetCourseStudents not implemented")
+}
+func (UnimplementedCoursesServiceServer) mustEmbedUnimplementedCoursesServiceServer() {}
+
+// UnsafeCoursesServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoursesServiceServer will
+// result in compilation errors.
+type UnsafeCoursesServiceServer interface {
+	mustEmbedUnimplementedCoursesServiceServer()
+}
+
+func RegisterCoursesServiceServer(s grpc.ServiceRegistrar, srv CoursesServiceServer) {
+	s.RegisterService(&CoursesService_ServiceDesc, srv)
+}
+
+func _CoursesService_GetCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCourseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).GetCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/GetCourse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).GetCourse(ctx, req.(*GetCourseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_ListCourses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCoursesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).ListCourses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/ListCourses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).ListCourses(ctx, req.(*ListCoursesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_CreateCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCourseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).CreateCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/CreateCourse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).CreateCourse(ctx, req.(*CreateCourseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_UpdateCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCourseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).UpdateCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/UpdateCourse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).UpdateCourse(ctx, req.(*UpdateCourseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_DeleteCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCourseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).DeleteCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/DeleteCourse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).DeleteCourse(ctx, req.(*DeleteCourseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_Enroll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).Enroll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/Enroll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).Enroll(ctx, req.(*EnrollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_Unenroll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnenrollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).Unenroll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/Unenroll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).Unenroll(ctx, req.(*UnenrollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_ListStudentCourses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStudentCoursesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoursesServiceServer).ListStudentCourses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CoursesService/ListStudentCourses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoursesServiceServer).ListStudentCourses(ctx, req.(*ListStudentCoursesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoursesService_GetCourseStudents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetCourseStudentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoursesServiceServer).GetCourseStudents(m, &coursesServiceGetCourseStudentsServer{stream})
+}
+
+type CoursesService_GetCourseStudentsServer interface {
+	Send(*GetStudentsResponse) error
+	grpc.ServerStream
+}
+
+type coursesServiceGetCourseStudentsServer struct {
+	grpc.ServerStream
+}
+
+func (x *coursesServiceGetCourseStudentsServer) Send(m *GetStudentsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// CoursesService_ServiceDesc is the grpc.ServiceDesc for CoursesService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CoursesService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "CoursesService",
+	HandlerType: (*CoursesServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCourse",
+			Handler:    _CoursesService_GetCourse_Handler,
+		},
+		{
+			MethodName: "ListCourses",
+			Handler:    _CoursesService_ListCourses_Handler,
+		},
+		{
+			MethodName: "CreateCourse",
+			Handler:    _CoursesService_CreateCourse_Handler,
+		},
+		{
+			MethodName: "UpdateCourse",
+			Handler:    _CoursesService_UpdateCourse_Handler,
+		},
+		{
+			MethodName: "DeleteCourse",
+			Handler:    _CoursesService_DeleteCourse_Handler,
+		},
+		{
+			MethodName: "Enroll",
+			Handler:    _CoursesService_Enroll_Handler,
+		},
+		{
+			MethodName: "Unenroll",
+			Handler:    _CoursesService_Unenroll_Handler,
+		},
+		{
+			MethodName: "ListStudentCourses",
+			Handler:    _CoursesService_ListStudentCourses_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetCourseStudents",
+			Handler:       _CoursesService_GetCourseStudents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/api.proto",
+}
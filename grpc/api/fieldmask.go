@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Fields that cannot be changed by update requests
+var (
+	immutableStudentFields = []string{"id", "courses"}
+	immutableCourseFields  = []string{"id"}
+)
+
+// UpdatedFields returns the fields of the student that are updated according to the update mask
+func (r *UpdateStudentRequest) UpdatedFields() ([]protoreflect.FieldDescriptor, error) {
+	var v violations
+	fields := v.maskedFields("update_mask", r.Student, r.UpdateMask.GetPaths(), immutableStudentFields...)
+	return fields, v.err()
+}
+
+// UpdatedFields returns the fields of the course that are updated according to the update mask
+func (r *UpdateCourseRequest) UpdatedFields() ([]protoreflect.FieldDescriptor, error) {
+	var v violations
+	fields := v.maskedFields("update_mask", r.Course, r.UpdateMask.GetPaths(), immutableCourseFields...)
+	return fields, v.err()
+}
+
+// CopyFields copies the given fields, e.g. those returned by UpdatedFields, from src to dst.
+// Fields that are unset in src are cleared in dst.
+func CopyFields(dst, src proto.Message, fields []protoreflect.FieldDescriptor) {
+	from, to := src.ProtoReflect(), dst.ProtoReflect()
+	for _, field := range fields {
+		if from.Has(field) {
+			to.Set(field, from.Get(field))
+		} else {
+			to.Clear(field)
+		}
+	}
+}
+
+// maskedFields returns the top-level fields of message named in paths.
+// Empty paths select all fields except the immutable ones.
+func (v *violations) maskedFields(field string, message proto.Message, paths []string, immutable ...string) []protoreflect.FieldDescriptor {
+	descriptor := message.ProtoReflect().Descriptor()
+	isImmutable := func(name string) bool {
+		for _, field := range immutable {
+			if field == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var fields []protoreflect.FieldDescriptor
+
+	if len(paths) == 0 {
+		all := descriptor.Fields()
+		for i := 0; i < all.Len(); i++ {
+			if !isImmutable(string(all.Get(i).Name())) {
+				fields = append(fields, all.Get(i))
+			}
+		}
+		return fields
+	}
+
+	for i, path := range paths {
+		f := descriptor.Fields().ByName(protoreflect.Name(path))
+		switch {
+		case f == nil:
+			v.add(fmt.Sprintf("%s.paths[%d]", field, i), fmt.Sprintf("unknown field %q", path))
+		case isImmutable(path):
+			v.add(fmt.Sprintf("%s.paths[%d]", field, i), fmt.Sprintf("field %q cannot be updated", path))
+		default:
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// names returns the set of names of fields
+func names(fields []protoreflect.FieldDescriptor) map[string]bool {
+	set := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		set[string(field.Name())] = true
+	}
+	return set
+}
@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"fmt"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"testing"
+)
+
+func TestUpdatedFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		request interface {
+			UpdatedFields() ([]protoreflect.FieldDescriptor, error)
+		}
+		updated []string
+		fields  []string
+	}{
+		{"all fields of student", &api.UpdateStudentRequest{Student: &api.Student{}}, []string{"name"}, nil},
+		{"student name", &api.UpdateStudentRequest{Student: &api.Student{}, UpdateMask: mask("name")}, []string{"name"}, nil},
+		{"student courses", &api.UpdateStudentRequest{Student: &api.Student{}, UpdateMask: mask("courses")}, nil, []string{"update_mask.paths[0]"}},
+		{"all fields of course", &api.UpdateCourseRequest{Course: &api.Course{}}, []string{"name", "description", "capacity"}, nil},
+		{"course description", &api.UpdateCourseRequest{Course: &api.Course{}, UpdateMask: mask("description")}, []string{"description"}, nil},
+		{"unknown field of course", &api.UpdateCourseRequest{Course: &api.Course{}, UpdateMask: mask("name", "teacher")}, []string{"name"}, []string{"update_mask.paths[1]"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updated, err := test.request.UpdatedFields()
+			var names []string
+			for _, field := range updated {
+				names = append(names, string(field.Name()))
+			}
+			if fmt.Sprint(names) != fmt.Sprint(test.updated) {
+				t.Errorf("UpdatedFields() = %v, want %v", names, test.updated)
+			}
+			if fields := violatedFields(t, err); fmt.Sprint(fields) != fmt.Sprint(test.fields) {
+				t.Errorf("UpdatedFields() violates %v, want %v", fields, test.fields)
+			}
+		})
+	}
+}
+
+func TestCopyFields(t *testing.T) {
+	stored := func() *api.Course {
+		return &api.Course{Id: 1, Name: "Cloud Computing", Description: "Containers", Capacity: 30}
+	}
+
+	tests := []struct {
+		name    string
+		request *api.UpdateCourseRequest
+		want    *api.Course
+	}{
+		{
+			"all fields",
+			&api.UpdateCourseRequest{Course: &api.Course{Id: 1, Name: "Distributed Systems"}},
+			// Unset fields of the request are cleared, the ID is kept
+			&api.Course{Id: 1, Name: "Distributed Systems"},
+		},
+		{
+			"masked field",
+			&api.UpdateCourseRequest{Course: &api.Course{Id: 1, Name: "Distributed Systems", Capacity: 10}, UpdateMask: mask("capacity")},
+			&api.Course{Id: 1, Name: "Cloud Computing", Description: "Containers", Capacity: 10},
+		},
+		{
+			"masked field cleared",
+			&api.UpdateCourseRequest{Course: &api.Course{Id: 1}, UpdateMask: mask("description")},
+			&api.Course{Id: 1, Name: "Cloud Computing", Capacity: 30},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, err := test.request.UpdatedFields()
+			if err != nil {
+				t.Fatalf("UpdatedFields: %v", err)
+			}
+			course := stored()
+			api.CopyFields(course, test.request.Course, fields)
+			if !proto.Equal(course, test.want) {
+				t.Errorf("CopyFields = %v, want %v", course, test.want)
+			}
+		})
+	}
+}
+
+// TestValidateMatchesUpdatedFields checks that the validation of update requests and the
+// fields updated by the handlers accept the same masks
+func TestValidateMatchesUpdatedFields(t *testing.T) {
+	student := &api.Student{Id: 1, Name: "Ada Lovelace"}
+	course := &api.Course{Id: 1, Name: "Cloud Computing"}
+
+	var requests []interface {
+		api.Validator
+		UpdatedFields() ([]protoreflect.FieldDescriptor, error)
+	}
+	for _, paths := range [][]string{nil, {"id"}, {"name"}, {"courses"}, {"description"}, {"capacity"}, {"name", "description", "capacity"}, {"teacher"}} {
+		requests = append(requests,
+			&api.UpdateStudentRequest{Student: student, UpdateMask: mask(paths...)},
+			&api.UpdateCourseRequest{Course: course, UpdateMask: mask(paths...)},
+		)
+	}
+	for _, request := range requests {
+		_, updateErr := request.UpdatedFields()
+		validateErr := request.Validate()
+		if fmt.Sprint(violatedFields(t, updateErr)) != fmt.Sprint(violatedFields(t, validateErr)) {
+			t.Errorf("%v: UpdatedFields() = %v, Validate() = %v, want the same violations", request, updateErr, validateErr)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits enforced by the Validate methods
+const (
+	MaxNameLength        = 100
+	MaxDescriptionLength = 1000
+	MaxPerMessage        = 1000
+	MaxPageSize          = 1000
+)
+
+// Validator is implemented by all request messages.
+// Validate returns nil or an InvalidArgument status with google.rpc.BadRequest details.
+type Validator interface {
+	Validate() error
+}
+
+// InvalidArgument returns an InvalidArgument status with a single field violation.
+func InvalidArgument(field, description string) error {
+	var v violations
+	v.add(field, description)
+	return v.err()
+}
+
+// violations collects the field violations of a request
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (v *violations) add(field, description string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+}
+
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(v))
+	for i, violation := range v {
+		descriptions[i] = fmt.Sprintf("%s: %s", violation.Field, violation.Description)
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(descriptions, "; "))
+	st, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		// Only fails if the details cannot be marshaled
+		panic(err)
+	}
+
+	return st.Err()
+}
+
+func (v *violations) id(field string, id int32) {
+	if id <= 0 {
+		v.add(field, "must be greater than 0")
+	}
+}
+
+// newStudent validates a student that is created by the server, i.e. without an ID
+func (v *violations) newStudent(field string, student *Student) {
+	if student == nil {
+		v.add(field, "is required")
+		return
+	}
+	if student.Id != 0 {
+		v.add(field+".id", "must not be set, IDs are assigned by the server")
+	}
+	if len(student.Courses) > 0 {
+		v.add(field+".courses", "is output only")
+	}
+	v.name(field+".name", student.Name)
+}
+
+func (v *violations) newStudents(field string, students []*Student) {
+	if len(students) == 0 {
+		v.add(field, "must not be empty")
+	}
+	for i, student := range students {
+		v.newStudent(fmt.Sprintf("%s[%d]", field, i), student)
+	}
+}
+
+func (v *violations) name(field, name string) {
+	switch {
+	case strings.TrimSpace(name) == "":
+		v.add(field, "must not be empty")
+	case !utf8.ValidString(name):
+		v.add(field, "must be valid UTF-8")
+	case utf8.RuneCountInString(name) > MaxNameLength:
+		v.add(field, fmt.Sprintf("must not be longer than %d characters", MaxNameLength))
+	}
+}
+
+func (r *GetStudentByIdRequest) Validate() error {
+	var v violations
+	v.id("id", r.Id)
+	return v.err()
+}
+
+func (v *violations) streamPage(perMessage, pageSize int32) {
+	if perMessage < 0 || perMessage > MaxPerMessage {
+		v.add("per_message", fmt.Sprintf("must be between 0 and %d", MaxPerMessage))
+	}
+	v.pageSize("page_size", pageSize)
+}
+
+func (r *GetStudentsRequest) Validate() error {
+	var v violations
+	v.streamPage(r.PerMessage, r.PageSize)
+	return v.err()
+}
+
+func (r *ImportStudentsRequest) Validate() error {
+	var v violations
+	v.newStudents("students", r.Students)
+	return v.err()
+}
+
+func (r *ImportStudentsV2Request) Validate() error {
+	var v violations
+	v.newStudents("students", r.Students)
+	return v.err()
+}
+
+func (r *CreateStudentRequest) Validate() error {
+	var v violations
+	v.newStudent("student", r.Student)
+	return v.err()
+}
+
+func (r *UpdateStudentRequest) Validate() error {
+	var v violations
+	if r.Student == nil {
+		v.add("student", "is required")
+		return v.err()
+	}
+	v.id("student.id", r.Student.Id)
+
+	updated := names(v.maskedFields("update_mask", r.Student, r.UpdateMask.GetPaths(), immutableStudentFields...))
+	if updated["name"] {
+		v.name("student.name", r.Student.Name)
+	}
+
+	return v.err()
+}
+
+func (r *DeleteStudentRequest) Validate() error {
+	var v violations
+	v.id("id", r.Id)
+	return v.err()
+}
+
+func (v *violations) description(field, description string) {
+	switch {
+	case !utf8.ValidString(description):
+		v.add(field, "must be valid UTF-8")
+	case utf8.RuneCountInString(description) > MaxDescriptionLength:
+		v.add(field, fmt.Sprintf("must not be longer than %d characters", MaxDescriptionLength))
+	}
+}
+
+func (v *violations) capacity(field string, capacity int32) {
+	if capacity < 0 {
+		v.add(field, "must not be negative")
+	}
+}
+
+func (v *violations) pageSize(field string, size int32) {
+	if size < 0 || size > MaxPageSize {
+		v.add(field, fmt.Sprintf("must be between 0 and %d", MaxPageSize))
+	}
+}
+
+func (r *GetCourseRequest) Validate() error {
+	var v violations
+	v.id("id", r.Id)
+	return v.err()
+}
+
+func (r *ListCoursesRequest) Validate() error {
+	var v violations
+	v.pageSize("page_size", r.PageSize)
+	return v.err()
+}
+
+func (r *CreateCourseRequest) Validate() error {
+	var v violations
+	if r.Course == nil {
+		v.add("course", "is required")
+		return v.err()
+	}
+	if r.Course.Id != 0 {
+		v.add("course.id", "must not be set, IDs are assigned by the server")
+	}
+	v.name("course.name", r.Course.Name)
+	v.description("course.description", r.Course.Description)
+	v.capacity("course.capacity", r.Course.Capacity)
+	return v.err()
+}
+
+func (r *UpdateCourseRequest) Validate() error {
+	var v violations
+	if r.Course == nil {
+		v.add("course", "is required")
+		return v.err()
+	}
+	v.id("course.id", r.Course.Id)
+
+	updated := names(v.maskedFields("update_mask", r.Course, r.UpdateMask.GetPaths(), immutableCourseFields...))
+	if updated["name"] {
+		v.name("course.name", r.Course.Name)
+	}
+	if updated["description"] {
+		v.description("course.description", r.Course.Description)
+	}
+	if updated["capacity"] {
+		v.capacity("course.capacity", r.Course.Capacity)
+	}
+
+	return v.err()
+}
+
+func (r *DeleteCourseRequest) Validate() error {
+	var v violations
+	v.id("id", r.Id)
+	return v.err()
+}
+
+func (r *EnrollRequest) Validate() error {
+	var v violations
+	v.id("student_id", r.StudentId)
+	v.id("course_id", r.CourseId)
+	return v.err()
+}
+
+func (r *UnenrollRequest) Validate() error {
+	var v violations
+	v.id("student_id", r.StudentId)
+	v.id("course_id", r.CourseId)
+	return v.err()
+}
+
+func (r *ListStudentCoursesRequest) Validate() error {
+	var v violations
+	v.id("student_id", r.StudentId)
+	return v.err()
+}
+
+func (r *GetCourseStudentsRequest) Validate() error {
+	var v violations
+	v.id("course_id", r.CourseId)
+	v.streamPage(r.PerMessage, r.PageSize)
+	return v.err()
+}
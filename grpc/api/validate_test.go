@@ -0,0 +1,123 @@
+package api_test
+
+import (
+	"fmt"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"strings"
+	"testing"
+)
+
+// violatedFields returns the fields of the google.rpc.BadRequest details of err
+func violatedFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %s, want InvalidArgument: %v", st.Code(), err)
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		t.Fatalf("%v has no field violations", err)
+	}
+	return fields
+}
+
+func mask(paths ...string) *fieldmaskpb.FieldMask {
+	return &fieldmaskpb.FieldMask{Paths: paths}
+}
+
+func TestValidate(t *testing.T) {
+	long := strings.Repeat("a", api.MaxNameLength+1)
+
+	tests := []struct {
+		name    string
+		request api.Validator
+		fields  []string
+	}{
+		{"get student", &api.GetStudentByIdRequest{Id: 1}, nil},
+		{"get student without ID", &api.GetStudentByIdRequest{}, []string{"id"}},
+		{"get student with negative ID", &api.GetStudentByIdRequest{Id: -1}, []string{"id"}},
+
+		{"get students", &api.GetStudentsRequest{PerMessage: 5, PageSize: api.MaxPageSize}, nil},
+		{"get students with defaults", &api.GetStudentsRequest{}, nil},
+		{"get students with negative page", &api.GetStudentsRequest{PerMessage: -1, PageSize: -1}, []string{"per_message", "page_size"}},
+		{"get students with too large page", &api.GetStudentsRequest{PerMessage: api.MaxPerMessage + 1, PageSize: api.MaxPageSize + 1}, []string{"per_message", "page_size"}},
+
+		{"import students", &api.ImportStudentsRequest{Students: []*api.Student{{Name: "Ada Lovelace"}}}, nil},
+		{"import no students", &api.ImportStudentsRequest{}, []string{"students"}},
+		{"import invalid students", &api.ImportStudentsV2Request{Students: []*api.Student{
+			{Name: "Ada Lovelace"},
+			{Id: 7, Name: " "},
+			nil,
+			{Name: long, Courses: []*api.Course{{Id: 1}}},
+			{Name: "\xff"},
+		}}, []string{"students[1].id", "students[1].name", "students[2]", "students[3].courses", "students[3].name", "students[4].name"}},
+
+		{"create student", &api.CreateStudentRequest{Student: &api.Student{Name: "Ada Lovelace"}}, nil},
+		{"create student without student", &api.CreateStudentRequest{}, []string{"student"}},
+		{"create student with ID", &api.CreateStudentRequest{Student: &api.Student{Id: 1, Name: "Ada Lovelace"}}, []string{"student.id"}},
+
+		{"update student", &api.UpdateStudentRequest{Student: &api.Student{Id: 1, Name: "Ada Lovelace"}}, nil},
+		{"update student name", &api.UpdateStudentRequest{Student: &api.Student{Id: 1, Name: "Ada Lovelace"}, UpdateMask: mask("name")}, nil},
+		{"update student without student", &api.UpdateStudentRequest{}, []string{"student"}},
+		{"update student without ID", &api.UpdateStudentRequest{Student: &api.Student{Name: "Ada Lovelace"}}, []string{"student.id"}},
+		{"update student with empty name", &api.UpdateStudentRequest{Student: &api.Student{Id: 1}}, []string{"student.name"}},
+		{"update student with immutable and unknown fields", &api.UpdateStudentRequest{
+			Student:    &api.Student{Id: 1, Name: "Ada Lovelace"},
+			UpdateMask: mask("name", "id", "courses", "email"),
+		}, []string{"update_mask.paths[1]", "update_mask.paths[2]", "update_mask.paths[3]"}},
+		// Fields that are not updated are not validated
+		{"update student without name in mask", &api.UpdateStudentRequest{Student: &api.Student{Id: 1}, UpdateMask: mask("id")}, []string{"update_mask.paths[0]"}},
+
+		{"delete student", &api.DeleteStudentRequest{Id: 1}, nil},
+		{"delete student without ID", &api.DeleteStudentRequest{}, []string{"id"}},
+
+		{"get course without ID", &api.GetCourseRequest{}, []string{"id"}},
+		{"list courses", &api.ListCoursesRequest{PageSize: api.MaxPageSize}, nil},
+		{"list courses with negative page size", &api.ListCoursesRequest{PageSize: -1}, []string{"page_size"}},
+		{"list courses with too large page size", &api.ListCoursesRequest{PageSize: api.MaxPageSize + 1}, []string{"page_size"}},
+
+		{"create course", &api.CreateCourseRequest{Course: &api.Course{Name: "Cloud Computing", Capacity: 30}}, nil},
+		{"create course without course", &api.CreateCourseRequest{}, []string{"course"}},
+		{"create invalid course", &api.CreateCourseRequest{Course: &api.Course{
+			Id:          1,
+			Description: strings.Repeat("a", api.MaxDescriptionLength+1),
+			Capacity:    -1,
+		}}, []string{"course.id", "course.name", "course.description", "course.capacity"}},
+
+		{"update course", &api.UpdateCourseRequest{Course: &api.Course{Id: 1, Name: "Cloud Computing"}}, nil},
+		{"update course capacity", &api.UpdateCourseRequest{Course: &api.Course{Id: 1, Capacity: 10}, UpdateMask: mask("capacity")}, nil},
+		{"update course with invalid capacity", &api.UpdateCourseRequest{Course: &api.Course{Id: 1, Capacity: -1}, UpdateMask: mask("capacity")}, []string{"course.capacity"}},
+		{"update all fields of course", &api.UpdateCourseRequest{Course: &api.Course{Id: 1, Capacity: -1}}, []string{"course.name", "course.capacity"}},
+		{"update course ID", &api.UpdateCourseRequest{Course: &api.Course{Id: 1}, UpdateMask: mask("id")}, []string{"update_mask.paths[0]"}},
+
+		{"enroll", &api.EnrollRequest{StudentId: 1, CourseId: 1}, nil},
+		{"enroll without IDs", &api.EnrollRequest{}, []string{"student_id", "course_id"}},
+		{"unenroll without IDs", &api.UnenrollRequest{}, []string{"student_id", "course_id"}},
+		{"list student courses without ID", &api.ListStudentCoursesRequest{}, []string{"student_id"}},
+		{"get course students", &api.GetCourseStudentsRequest{CourseId: 1}, nil},
+		{"get course students with invalid page", &api.GetCourseStudentsRequest{PageSize: api.MaxPageSize + 1}, []string{"course_id", "page_size"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields := violatedFields(t, test.request.Validate())
+			if fmt.Sprint(fields) != fmt.Sprint(test.fields) {
+				t.Errorf("Validate() violates %v, want %v", fields, test.fields)
+			}
+		})
+	}
+}
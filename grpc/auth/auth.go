@@ -0,0 +1,105 @@
+// Package auth verifies JWT bearer tokens against a JSON Web Key Set (JWKS)
+// and passes the claims of verified tokens to the handlers in the context.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// ErrUnknownKey is returned when a token is signed with a key that is not in the key set.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Claims are the claims of a verified token.
+type Claims struct {
+	jwt.RegisteredClaims
+	// Roles of the subject, used for authorization
+	Roles []string `json:"roles,omitempty"`
+}
+
+type claimsKey struct{}
+
+// NewContext returns a context carrying the claims of a verified token.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims of the verified token of a request.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// signingMethods are the accepted algorithms. Symmetric algorithms are not accepted,
+// as the keys of the key set are public.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// leeway allows for clock skew between the issuer and the server
+const leeway = 30 * time.Second
+
+// Verifier verifies tokens signed with a key of a key set.
+type Verifier struct {
+	keys   KeySet
+	parser *jwt.Parser
+}
+
+// NewVerifier returns a verifier for tokens signed with a key of keys. Tokens must expire.
+// If issuer or audience are not empty, the iss and aud claims of tokens must match them.
+func NewVerifier(keys KeySet, issuer, audience string) *Verifier {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	return &Verifier{keys: keys, parser: jwt.NewParser(options...)}
+}
+
+// Verify returns the claims of token if it is valid.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	var claims Claims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(token *jwt.Token) (any, error) {
+		id, _ := token.Header["kid"].(string)
+		key, err := v.keys.Key(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if key.Algorithm != "" && key.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("key %q is for %s, not %s", id, key.Algorithm, token.Method.Alg())
+		}
+		return key.Key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// lookup returns the signing key with the given ID. Tokens without an ID may be signed
+// with the only key of a set.
+func lookup(set *jose.JSONWebKeySet, id string) (*jose.JSONWebKey, error) {
+	if id == "" {
+		if len(set.Keys) == 1 {
+			return &set.Keys[0], nil
+		}
+		return nil, fmt.Errorf("%w: the token has no key ID", ErrUnknownKey)
+	}
+
+	for _, key := range set.Key(id) {
+		if key.Use == "" || key.Use == "sig" {
+			return &key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+}
@@ -0,0 +1,156 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/auth"
+	"testing"
+	"time"
+)
+
+func ecdsaKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// sign returns a token with claims signed by key, naming the key ID kid unless it is empty
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// staticKeys is a key set that never changes
+func staticKeys(keys ...jose.JSONWebKey) auth.KeySet {
+	set := &jose.JSONWebKeySet{Keys: keys}
+	return auth.KeySetFunc(func(context.Context) (*jose.JSONWebKeySet, error) {
+		return set, nil
+	})
+}
+
+func claims(subject string, expiresIn time.Duration) *auth.Claims {
+	now := time.Now()
+	return &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    "https://issuer.example.com",
+			Audience:  jwt.ClaimStrings{"students"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+		},
+		Roles: []string{"reader"},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	es := ecdsaKey(t)
+	other := ecdsaKey(t)
+	rs, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := staticKeys(
+		jose.JSONWebKey{Key: es.Public(), KeyID: "es", Algorithm: "ES256", Use: "sig"},
+		jose.JSONWebKey{Key: rs.Public(), KeyID: "ps", Algorithm: "PS256", Use: "sig"},
+		jose.JSONWebKey{Key: other.Public(), KeyID: "enc", Use: "enc"},
+	)
+	verifier := auth.NewVerifier(keys, "https://issuer.example.com", "students")
+
+	// The public key, as an attacker would use it as the secret of HS256
+	der, err := x509.MarshalPKIXPublicKey(es.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	valid := claims("ada", time.Hour)
+	noExpiry := claims("ada", time.Hour)
+	noExpiry.ExpiresAt = nil
+	wrongIssuer := claims("ada", time.Hour)
+	wrongIssuer.Issuer = "https://other.example.com"
+	wrongAudience := claims("ada", time.Hour)
+	wrongAudience.Audience = jwt.ClaimStrings{"other"}
+	notYetValid := claims("ada", time.Hour)
+	notYetValid.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", sign(t, jwt.SigningMethodES256, es, "es", valid), nil},
+		{"valid PS256", sign(t, jwt.SigningMethodPS256, rs, "ps", valid), nil},
+		{"expired within leeway", sign(t, jwt.SigningMethodES256, es, "es", claims("ada", -10*time.Second)), nil},
+		{"expired", sign(t, jwt.SigningMethodES256, es, "es", claims("ada", -time.Minute)), jwt.ErrTokenExpired},
+		{"without expiry", sign(t, jwt.SigningMethodES256, es, "es", noExpiry), jwt.ErrTokenRequiredClaimMissing},
+		{"not yet valid", sign(t, jwt.SigningMethodES256, es, "es", notYetValid), jwt.ErrTokenNotValidYet},
+		{"wrong issuer", sign(t, jwt.SigningMethodES256, es, "es", wrongIssuer), jwt.ErrTokenInvalidIssuer},
+		{"wrong audience", sign(t, jwt.SigningMethodES256, es, "es", wrongAudience), jwt.ErrTokenInvalidAudience},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "es", valid), jwt.ErrTokenSignatureInvalid},
+		{"HS256 with the public key", sign(t, jwt.SigningMethodHS256, publicPEM, "es", valid), jwt.ErrTokenSignatureInvalid},
+		{"RS256 with a PS256 key", sign(t, jwt.SigningMethodRS256, rs, "ps", valid), jwt.ErrTokenUnverifiable},
+		{"signed by another key", sign(t, jwt.SigningMethodES256, other, "es", valid), jwt.ErrTokenSignatureInvalid},
+		{"unknown key", sign(t, jwt.SigningMethodES256, es, "unknown", valid), auth.ErrUnknownKey},
+		{"encryption key", sign(t, jwt.SigningMethodES256, other, "enc", valid), auth.ErrUnknownKey},
+		{"no key ID with several keys", sign(t, jwt.SigningMethodES256, es, "", valid), auth.ErrUnknownKey},
+		{"malformed", "not.a.token", jwt.ErrTokenMalformed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := verifier.Verify(context.Background(), test.token)
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if got.Subject != "ada" || len(got.Roles) != 1 || got.Roles[0] != "reader" {
+					t.Errorf("Verify = %+v, want the claims of ada", got)
+				}
+				return
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("Verify error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutKeyID(t *testing.T) {
+	key := ecdsaKey(t)
+	verifier := auth.NewVerifier(staticKeys(jose.JSONWebKey{Key: key.Public(), Algorithm: "ES256"}), "", "")
+
+	// The only key of a set is used for tokens that name none
+	if _, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodES256, key, "", claims("ada", time.Hour))); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := auth.FromContext(ctx); ok {
+		t.Error("FromContext of an empty context returned claims")
+	}
+
+	want := claims("ada", time.Hour)
+	if got, ok := auth.FromContext(auth.NewContext(ctx, want)); !ok || got != want {
+		t.Errorf("FromContext = %v, %v, want %v", got, ok, want)
+	}
+}
@@ -0,0 +1,13 @@
+package auth
+
+import "time"
+
+// Backdate moves the last fetch of a remote key set d into the past, instead of waiting for maxAge or minRefetch
+func Backdate(keys KeySet, d time.Duration) {
+	r := keys.(*remoteKeySet)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetched = r.fetched.Add(-d)
+}
+
+const MinRefetch = minRefetch
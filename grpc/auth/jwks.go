@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-jose/go-jose/v3"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// KeySet returns the public key with the given ID, which is empty if the token names none.
+type KeySet interface {
+	Key(ctx context.Context, id string) (*jose.JSONWebKey, error)
+}
+
+// KeySetFunc is a KeySet that looks the key up in the set returned by the function,
+// e.g. one loaded from a file.
+type KeySetFunc func(ctx context.Context) (*jose.JSONWebKeySet, error)
+
+func (f KeySetFunc) Key(ctx context.Context, id string) (*jose.JSONWebKey, error) {
+	set, err := f(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lookup(set, id)
+}
+
+// ParseKeySet parses a JWKS document. All keys must be valid public keys.
+func ParseKeySet(data []byte) (*jose.JSONWebKeySet, error) {
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	if len(set.Keys) == 0 {
+		return nil, errors.New("the key set is empty")
+	}
+	for _, key := range set.Keys {
+		if !key.Valid() || !key.IsPublic() {
+			return nil, fmt.Errorf("key %q is not a valid public key", key.KeyID)
+		}
+	}
+	return &set, nil
+}
+
+// minRefetch limits how often a remote key set is fetched because of unknown key IDs
+const minRefetch = 10 * time.Second
+
+// fetchTimeout bounds fetches after the first one, which do not use the context of the RPC
+const fetchTimeout = 5 * time.Second
+
+// remoteKeySet caches a key set fetched from a URL
+type remoteKeySet struct {
+	url    string
+	client *http.Client
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	set     *jose.JSONWebKeySet
+	fetched time.Time
+	// fetching is closed when the fetch in progress is finished, nil if there is none
+	fetching chan struct{}
+}
+
+// NewRemoteKeySet returns a key set fetched from url. It is fetched again after maxAge and,
+// at most every 10 seconds, when a token names an unknown key, e.g. after a key rotation.
+// If fetching fails, the previous keys are used. The first fetch must succeed.
+func NewRemoteKeySet(ctx context.Context, url string, client *http.Client, maxAge time.Duration) (KeySet, error) {
+	r := &remoteKeySet{url: url, client: client, maxAge: maxAge, fetched: time.Now()}
+
+	set, err := r.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.set = set
+	return r, nil
+}
+
+func (r *remoteKeySet) Key(ctx context.Context, id string) (*jose.JSONWebKey, error) {
+	r.mu.Lock()
+	set, stale := r.set, time.Since(r.fetched) > r.maxAge
+	r.mu.Unlock()
+
+	if stale {
+		set = r.refresh(ctx, r.maxAge)
+	}
+
+	key, err := lookup(set, id)
+	if errors.Is(err, ErrUnknownKey) {
+		key, err = lookup(r.refresh(ctx, minRefetch), id)
+	}
+	return key, err
+}
+
+// refresh fetches the keys if they are older than age, or joins the fetch in progress, and returns
+// the keys once it is finished or ctx is done. If fetching fails, the previous keys are returned.
+// The fetch does not use ctx, so a cancelled RPC does not fail it for the other RPCs waiting for it.
+func (r *remoteKeySet) refresh(ctx context.Context, age time.Duration) *jose.JSONWebKeySet {
+	r.mu.Lock()
+	if r.fetching == nil {
+		if time.Since(r.fetched) <= age {
+			set := r.set
+			r.mu.Unlock()
+			return set
+		}
+
+		// Failed attempts count as well, so an unreachable URL is not requested for every token
+		r.fetched = time.Now()
+		r.fetching = make(chan struct{})
+		go func(done chan struct{}) {
+			ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+			defer cancel()
+			set, err := r.fetch(ctx)
+
+			r.mu.Lock()
+			if err == nil {
+				r.set = set
+			}
+			r.fetching = nil
+			r.mu.Unlock()
+			close(done)
+		}(r.fetching)
+	}
+	fetching := r.fetching
+	r.mu.Unlock()
+
+	select {
+	case <-fetching:
+	case <-ctx.Done():
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.set
+}
+
+// fetch returns the current keys from the URL
+func (r *remoteKeySet) fetch(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", r.url, response.Status)
+	}
+
+	// Key sets are small
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	set, err := ParseKeySet(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.url, err)
+	}
+	return set, nil
+}
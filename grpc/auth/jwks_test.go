@@ -0,0 +1,289 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/auth"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwksServer serves a key set that tests can replace, and counts the requests
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     []jose.JSONWebKey
+	fail     bool
+	requests int
+	// held blocks requests until it is closed
+	held chan struct{}
+}
+
+func newJWKSServer(t *testing.T, keys ...jose.JSONWebKey) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		held := s.held
+		s.mu.Unlock()
+		if held != nil {
+			<-held
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(keys ...jose.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+// hold blocks the following requests until release is called
+func (s *jwksServer) hold() (release func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := make(chan struct{})
+	s.held = held
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.held = nil
+			s.mu.Unlock()
+			close(held)
+		})
+	}
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func publicKey(t *testing.T, id string) jose.JSONWebKey {
+	t.Helper()
+	return jose.JSONWebKey{Key: ecdsaKey(t).Public(), KeyID: id, Algorithm: "ES256", Use: "sig"}
+}
+
+func TestRemoteKeySetRefetchesUnknownKeys(t *testing.T) {
+	ctx := context.Background()
+	old, rotated := publicKey(t, "old"), publicKey(t, "new")
+	server := newJWKSServer(t, old)
+
+	keys, err := auth.NewRemoteKeySet(ctx, server.URL, server.Client(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewRemoteKeySet: %v", err)
+	}
+	if _, err := keys.Key(ctx, "old"); err != nil {
+		t.Fatalf("Key(old): %v", err)
+	}
+
+	// Right after a fetch, unknown keys do not cause another one
+	server.set(old, rotated)
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Key(ctx, "new"); !errors.Is(err, auth.ErrUnknownKey) {
+			t.Errorf("Key(new) before the rotation is fetched = %v, want ErrUnknownKey", err)
+		}
+	}
+	if got := server.count(); got != 1 {
+		t.Errorf("%d requests right after the first fetch, want 1", got)
+	}
+
+	auth.Backdate(keys, auth.MinRefetch+time.Second)
+	if _, err := keys.Key(ctx, "new"); err != nil {
+		t.Errorf("Key(new) after the rotation: %v", err)
+	}
+	if got := server.count(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+
+	// A token with a made-up key ID cannot trigger a fetch per request
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Key(ctx, "made-up"); !errors.Is(err, auth.ErrUnknownKey) {
+			t.Errorf("Key(made-up) = %v, want ErrUnknownKey", err)
+		}
+	}
+	if got := server.count(); got != 2 {
+		t.Errorf("%d requests after unknown keys, want 2", got)
+	}
+}
+
+func TestRemoteKeySetMaxAge(t *testing.T) {
+	ctx := context.Background()
+	old, rotated := publicKey(t, "old"), publicKey(t, "new")
+	server := newJWKSServer(t, old)
+
+	keys, err := auth.NewRemoteKeySet(ctx, server.URL, server.Client(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewRemoteKeySet: %v", err)
+	}
+
+	// Removed keys are dropped once the set is fetched again
+	server.set(rotated)
+	auth.Backdate(keys, 2*time.Minute)
+	if _, err := keys.Key(ctx, "old"); !errors.Is(err, auth.ErrUnknownKey) {
+		t.Errorf("Key(old) after maxAge = %v, want ErrUnknownKey", err)
+	}
+	if _, err := keys.Key(ctx, "new"); err != nil {
+		t.Errorf("Key(new) after maxAge: %v", err)
+	}
+	if got := server.count(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestRemoteKeySetFetchesInTheBackground(t *testing.T) {
+	ctx := context.Background()
+	old, rotated := publicKey(t, "old"), publicKey(t, "new")
+	server := newJWKSServer(t, old)
+
+	keys, err := auth.NewRemoteKeySet(ctx, server.URL, server.Client(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewRemoteKeySet: %v", err)
+	}
+
+	server.set(old, rotated)
+	release := server.hold()
+	defer release()
+	auth.Backdate(keys, auth.MinRefetch+time.Second)
+
+	// The RPC that starts the fetch is cancelled while it waits for it
+	cancelled, cancel := context.WithCancel(ctx)
+	result := make(chan error, 1)
+	go func() {
+		_, err := keys.Key(cancelled, "new")
+		result <- err
+	}()
+	for deadline := time.Now().Add(5 * time.Second); server.count() < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the key set was not fetched again")
+		}
+	}
+
+	// RPCs with known keys do not wait for the fetch
+	known := make(chan error, 1)
+	go func() {
+		_, err := keys.Key(ctx, "old")
+		known <- err
+	}()
+	select {
+	case err := <-known:
+		if err != nil {
+			t.Errorf("Key(old) during the fetch: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Key(old) waits for the fetch")
+	}
+
+	cancel()
+	select {
+	case err := <-result:
+		if !errors.Is(err, auth.ErrUnknownKey) {
+			t.Errorf("Key(new) of the cancelled RPC = %v, want ErrUnknownKey", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the cancelled RPC waits for the fetch")
+	}
+
+	// The fetch continues for the other RPCs
+	release()
+	if _, err := keys.Key(ctx, "new"); err != nil {
+		t.Errorf("Key(new) after the fetch: %v", err)
+	}
+	if got := server.count(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestRemoteKeySetKeepsKeysWhenFetchFails(t *testing.T) {
+	ctx := context.Background()
+	server := newJWKSServer(t, publicKey(t, "old"))
+
+	keys, err := auth.NewRemoteKeySet(ctx, server.URL, server.Client(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewRemoteKeySet: %v", err)
+	}
+
+	server.setFail(true)
+	auth.Backdate(keys, 2*time.Minute)
+	if _, err := keys.Key(ctx, "old"); err != nil {
+		t.Errorf("Key(old) while the URL fails: %v", err)
+	}
+	// The failed attempt counts as a fetch
+	if _, err := keys.Key(ctx, "old"); err != nil {
+		t.Errorf("Key(old) while the URL fails: %v", err)
+	}
+	if got := server.count(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestNewRemoteKeySetErrors(t *testing.T) {
+	ctx := context.Background()
+
+	failing := newJWKSServer(t, publicKey(t, "key"))
+	failing.setFail(true)
+	if _, err := auth.NewRemoteKeySet(ctx, failing.URL, failing.Client(), time.Minute); err == nil {
+		t.Error("NewRemoteKeySet of an unavailable URL succeeded")
+	}
+
+	empty := newJWKSServer(t)
+	if _, err := auth.NewRemoteKeySet(ctx, empty.URL, empty.Client(), time.Minute); err == nil {
+		t.Error("NewRemoteKeySet of an empty key set succeeded")
+	}
+}
+
+func TestParseKeySet(t *testing.T) {
+	private := jose.JSONWebKey{Key: ecdsaKey(t), KeyID: "private", Algorithm: "ES256"}
+	privateJSON, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{private}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicJSON, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{publicKey(t, "public")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"public key", string(publicJSON), false},
+		{"private key", string(privateJSON), true},
+		{"empty", `{"keys": []}`, true},
+		{"not JSON", `keys`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := auth.ParseKeySet([]byte(test.data))
+			if (err != nil) != test.wantErr {
+				t.Errorf("ParseKeySet error = %v, want error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
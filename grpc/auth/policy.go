@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+)
+
+// Policy grants roles the permission to call methods. Methods that are not granted
+// to any role of a caller are denied. A policy is declared in YAML, mapping roles to
+// full method names, all methods of a service (/Service/*) or all methods (*):
+//
+//	roles:
+//	  reader:
+//	    - /StudentsService/GetStudentById
+//	    - /StudentsService/GetStudents
+//	  importer:
+//	    - /StudentsService/ImportStudents
+//	    - /StudentsService/ImportStudentsV2
+//	  admin:
+//	    - "*"
+type Policy struct {
+	// Role -> method patterns
+	roles map[string][]string
+}
+
+// ParsePolicy parses a policy declared in YAML.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var document struct {
+		Roles map[string][]string `yaml:"roles"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	for role, patterns := range document.Roles {
+		for _, pattern := range patterns {
+			if !validPattern(pattern) {
+				return nil, fmt.Errorf("role %s: invalid method %q, expected /Service/Method, /Service/* or *", role, pattern)
+			}
+		}
+	}
+
+	return &Policy{roles: document.Roles}, nil
+}
+
+func validPattern(pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	service, method, ok := strings.Cut(strings.TrimPrefix(pattern, "/"), "/")
+	// Wildcards only match whole methods, a pattern like /Service/Get* would never match
+	wildcards := strings.Contains(service, "*") || (method != "*" && strings.Contains(method, "*"))
+	return strings.HasPrefix(pattern, "/") && ok && service != "" && method != "" && !strings.Contains(method, "/") && !wildcards
+}
+
+// Allow returns the first of roles, in alphabetical order, that may call the full method
+// name, e.g. /StudentsService/GetStudents. ok is false if none of the roles may call it.
+func (p *Policy) Allow(method string, roles []string) (role string, ok bool) {
+	sorted := append([]string(nil), roles...)
+	sort.Strings(sorted)
+
+	for _, role := range sorted {
+		for _, pattern := range p.roles[role] {
+			if matches(pattern, method) {
+				return role, true
+			}
+		}
+	}
+	return "", false
+}
+
+func matches(pattern, method string) bool {
+	if pattern == "*" || pattern == method {
+		return true
+	}
+	service, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(method, service+"/")
+}
@@ -0,0 +1,119 @@
+package auth_test
+
+import (
+	"github.com/simonhammes/301-cloud-computing-project/grpc/auth"
+	"os"
+	"testing"
+)
+
+const testPolicy = `
+roles:
+  reader:
+    - /StudentsService/GetStudentById
+    - /StudentsService/GetStudents
+  importer:
+    - /StudentsService/ImportStudents
+  courses:
+    - /CoursesService/*
+  admin:
+    - "*"
+`
+
+func TestPolicyAllow(t *testing.T) {
+	policy, err := auth.ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		roles    []string
+		wantRole string
+		wantOK   bool
+	}{
+		{"granted method", "/StudentsService/GetStudents", []string{"reader"}, "reader", true},
+		{"other method of the service", "/StudentsService/DeleteStudent", []string{"reader"}, "", false},
+		{"method of another role", "/StudentsService/ImportStudents", []string{"reader"}, "", false},
+		{"no roles", "/StudentsService/GetStudents", nil, "", false},
+		{"unknown role", "/StudentsService/GetStudents", []string{"writer"}, "", false},
+		{"unknown and known role", "/StudentsService/GetStudents", []string{"writer", "reader"}, "reader", true},
+		{"service wildcard", "/CoursesService/Enroll", []string{"courses"}, "courses", true},
+		{"service wildcard, other service", "/StudentsService/GetStudents", []string{"courses"}, "", false},
+		{"service wildcard, service prefix", "/CoursesServiceV2/Enroll", []string{"courses"}, "", false},
+		{"wildcard", "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", []string{"admin"}, "admin", true},
+		{"first role in alphabetical order", "/StudentsService/GetStudents", []string{"reader", "admin"}, "admin", true},
+		{"prefix of a method", "/StudentsService/GetStudent", []string{"reader"}, "", false},
+		{"case matters", "/studentsservice/getstudents", []string{"reader"}, "", false},
+		{"role names are case-sensitive", "/StudentsService/GetStudents", []string{"Reader"}, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			role, ok := policy.Allow(test.method, test.roles)
+			if role != test.wantRole || ok != test.wantOK {
+				t.Errorf("Allow(%s, %v) = %q, %v, want %q, %v", test.method, test.roles, role, ok, test.wantRole, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestPolicyAllowKeepsRoles(t *testing.T) {
+	policy, err := auth.ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+
+	roles := []string{"reader", "admin"}
+	policy.Allow("/StudentsService/GetStudents", roles)
+	if roles[0] != "reader" || roles[1] != "admin" {
+		t.Errorf("Allow reordered the roles of the caller to %v", roles)
+	}
+}
+
+func TestEmptyPolicyDeniesEverything(t *testing.T) {
+	policy, err := auth.ParsePolicy([]byte("roles: {}\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	if role, ok := policy.Allow("/StudentsService/GetStudents", []string{"admin"}); ok {
+		t.Errorf("Allow = %q, true, want a denial", role)
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := map[string]string{
+		"method without service": "roles:\n  reader:\n    - GetStudents\n",
+		"missing leading slash":  "roles:\n  reader:\n    - StudentsService/GetStudents\n",
+		"empty method":           "roles:\n  reader:\n    - /StudentsService/\n",
+		"empty service":          "roles:\n  reader:\n    - //GetStudents\n",
+		"nested method":          "roles:\n  reader:\n    - /StudentsService/Get/Students\n",
+		"prefix wildcard":        "roles:\n  reader:\n    - /StudentsService/Get*\n",
+		"wildcard service":       "roles:\n  reader:\n    - /*/GetStudents\n",
+		"service-only wildcard":  "roles:\n  reader:\n    - /*\n",
+		"empty pattern":          "roles:\n  reader:\n    - \"\"\n",
+		"unknown field":          "role:\n  reader:\n    - /StudentsService/GetStudents\n",
+		"methods are not a list": "roles:\n  reader: /StudentsService/GetStudents\n",
+		"not YAML":               "roles: [",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := auth.ParsePolicy([]byte(data)); err == nil {
+				t.Errorf("ParsePolicy(%q) succeeded", data)
+			}
+		})
+	}
+}
+
+func TestExamplePolicy(t *testing.T) {
+	data, err := os.ReadFile("../policy.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := auth.ParsePolicy(data)
+	if err != nil {
+		t.Fatalf("ParsePolicy(policy.yaml): %v", err)
+	}
+	if _, ok := policy.Allow("/StudentsService/GetStudents", []string{"reader"}); !ok {
+		t.Error("policy.yaml does not allow readers to call GetStudents")
+	}
+}
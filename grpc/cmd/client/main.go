@@ -2,11 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/go-faker/faker/v4"
 	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/config"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"io"
 	"log"
 	"os"
@@ -14,7 +22,7 @@ import (
 )
 
 func usage() string {
-	return "Usage: client <unary|server-streaming|client-streaming|bidirectional>"
+	return "Usage: client [flags] <unary|server-streaming|client-streaming|bidirectional|crud|courses|enroll>"
 }
 
 func generateFakeStudents(n int) []*api.Student {
@@ -30,56 +38,73 @@ func generateFakeStudents(n int) []*api.Student {
 	return students
 }
 
-func unaryExample(client api.StudentsServiceClient) {
+func unaryExample(ctx context.Context, client api.StudentsServiceClient) error {
 	request := api.GetStudentByIdRequest{Id: 3}
 
 	log.Print("Calling GetStudentById()")
-	response, err := client.GetStudentById(context.Background(), &request)
+	response, err := client.GetStudentById(ctx, &request)
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return err
 	}
 
 	log.Printf("Student: ID = %d, Name = %s", response.Id, response.Name)
+	return nil
 }
 
-func serverStreamingExample(client api.StudentsServiceClient) {
-	request := api.GetStudentsRequest{PerMessage: 5}
-
-	log.Print("Calling GetStudents()")
-	stream, err := client.GetStudents(context.Background(), &request)
+func serverStreamingExample(ctx context.Context, client api.StudentsServiceClient) error {
+	// Fetch two pages of 20 students each
+	pageToken := ""
+	for page := 1; page <= 2; page++ {
+		request := api.GetStudentsRequest{PerMessage: 5, PageSize: 20, PageToken: pageToken}
 
-	if err != nil {
-		log.Fatalf("Error: %v", err)
-	}
+		log.Printf("Calling GetStudents() for page %d", page)
+		stream, err := client.GetStudents(ctx, &request)
 
-	for {
-		response, err := stream.Recv()
-		if err == io.EOF {
-			break
-		}
 		if err != nil {
-			log.Fatalf("Error: %v", err)
+			return err
 		}
 
-		for _, student := range response.Students {
-			log.Printf("Student: %s", student.Name)
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, student := range response.Students {
+				log.Printf("Student: %s", student.Name)
+			}
+
+			pageToken = response.NextPageToken
+		}
+
+		if pageToken == "" {
+			// No more students
+			return nil
 		}
 	}
+	return nil
 }
 
-func clientStreamingExample(client api.StudentsServiceClient) {
+func clientStreamingExample(ctx context.Context, client api.StudentsServiceClient) error {
 	log.Print("Calling ImportStudents()")
-	stream, err := client.ImportStudents(context.Background())
+	stream, err := client.ImportStudents(ctx)
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return err
 	}
 
 	for i := 1; i <= 10; i++ {
 		message := api.ImportStudentsRequest{Students: generateFakeStudents(5)}
 		log.Printf("Importing %d students", len(message.Students))
 		err := stream.Send(&message)
+		if err == io.EOF {
+			// The server ended the stream, CloseAndRecv returns the status
+			break
+		}
 		if err != nil {
-			log.Fatalf("Error: %v", err)
+			return err
 		}
 
 		// Do some work
@@ -88,24 +113,25 @@ func clientStreamingExample(client api.StudentsServiceClient) {
 
 	summary, err := stream.CloseAndRecv()
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return err
 	}
 
 	log.Printf("Summary: Imported %d students", summary.Count)
+	return nil
 }
 
-func bidirectionalStreamingExample(client api.StudentsServiceClient) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func bidirectionalStreamingExample(ctx context.Context, client api.StudentsServiceClient) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	log.Print("Calling ImportStudentsV2()")
 	stream, err := client.ImportStudentsV2(ctx)
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return err
 	}
 
-	// Create a channel
-	waitc := make(chan struct{})
+	// Receives the result of the goroutine
+	errc := make(chan error, 1)
 
 	// Start goroutine to receive messages from the server
 	go func() {
@@ -113,11 +139,12 @@ func bidirectionalStreamingExample(client api.StudentsServiceClient) {
 			in, err := stream.Recv()
 			if err == io.EOF {
 				// No more messages
-				close(waitc)
+				errc <- nil
 				return
 			}
 			if err != nil {
-				log.Fatalf("Error: %v", err)
+				errc <- err
+				return
 			}
 			log.Printf("Received %d students with generated IDs", len(in.Students))
 		}
@@ -129,8 +156,12 @@ func bidirectionalStreamingExample(client api.StudentsServiceClient) {
 		log.Printf("Importing %d students", len(message.Students))
 
 		err := stream.Send(&message)
+		if err == io.EOF {
+			// The server ended the stream, Recv returns the status
+			break
+		}
 		if err != nil {
-			log.Fatalf("Error: %v", err)
+			return err
 		}
 
 		// Do some work
@@ -138,15 +169,136 @@ func bidirectionalStreamingExample(client api.StudentsServiceClient) {
 	}
 
 	stream.CloseSend()
-	<-waitc
+	return <-errc
+}
+
+func crudExample(ctx context.Context, client api.StudentsServiceClient) error {
+	log.Print("Calling CreateStudent()")
+	created, err := client.CreateStudent(ctx, &api.CreateStudentRequest{Student: &api.Student{Name: "Jonh Doe"}})
+	if err != nil {
+		return err
+	}
+	log.Printf("Created student: ID = %d, Name = %s", created.Id, created.Name)
+
+	// Fix the typo, only the name is sent
+	log.Print("Calling UpdateStudent()")
+	request := api.UpdateStudentRequest{
+		Student:    &api.Student{Id: created.Id, Name: "John Doe"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	}
+	updated, err := client.UpdateStudent(ctx, &request)
+	if err != nil {
+		return err
+	}
+	log.Printf("Updated student: ID = %d, Name = %s", updated.Id, updated.Name)
+
+	log.Print("Calling DeleteStudent()")
+	if _, err := client.DeleteStudent(ctx, &api.DeleteStudentRequest{Id: created.Id}); err != nil {
+		return err
+	}
+	log.Printf("Deleted student %d", created.Id)
+	return nil
 }
 
+func coursesExample(ctx context.Context, client api.CoursesServiceClient) error {
+	log.Print("Calling ListCourses()")
+	response, err := client.ListCourses(ctx, &api.ListCoursesRequest{})
+	if err != nil {
+		return err
+	}
+
+	for _, course := range response.Courses {
+		log.Printf("Course: ID = %d, Name = %s, Description = %s", course.Id, course.Name, course.Description)
+	}
+	return nil
+}
+
+func enrollExample(ctx context.Context, client api.CoursesServiceClient) error {
+	log.Print("Calling Enroll()")
+	_, err := client.Enroll(ctx, &api.EnrollRequest{StudentId: 3, CourseId: 1})
+	if status.Code(err) == codes.AlreadyExists {
+		log.Print("Student 3 is already enrolled")
+	} else if err != nil {
+		return err
+	}
+
+	log.Print("Calling ListStudentCourses()")
+	response, err := client.ListStudentCourses(ctx, &api.ListStudentCoursesRequest{StudentId: 3})
+	if err != nil {
+		return err
+	}
+	for _, course := range response.Courses {
+		log.Printf("Student 3 is enrolled in: %s", course.Name)
+	}
+
+	log.Print("Calling GetCourseStudents()")
+	stream, err := client.GetCourseStudents(ctx, &api.GetCourseStudentsRequest{CourseId: 1})
+	if err != nil {
+		return err
+	}
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, student := range response.Students {
+			log.Printf("Enrolled in course 1: %s", student.Name)
+		}
+	}
+	return nil
+}
+
+// errUsage is returned by run if the example is missing or unknown
+var errUsage = errors.New("invalid usage")
+
 func main() {
-	// Disable TLS
-	options := grpc.WithTransportCredentials(insecure.NewCredentials())
-	connection, err := grpc.Dial("127.0.0.1:3000", options)
+	if err := run(); errors.Is(err, errUsage) {
+		flag.Usage()
+		os.Exit(1)
+	} else if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// run runs the example of the arguments. It returns errors instead of exiting,
+// so the deferred functions close the connection and export the spans.
+func run() error {
+	var transport config.Client
+	transport.RegisterFlags(flag.CommandLine)
+	var tracing config.Tracing
+	tracing.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), usage())
+		flag.PrintDefaults()
+	}
+	if err := config.Load(flag.CommandLine, config.ClientEnvPrefix, os.Args[1:]); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if flag.NArg() < 1 {
+		return errUsage
+	}
+
+	stopTracing, err := tracing.Start(context.Background(), "students-client")
+	if err != nil {
+		return fmt.Errorf("invalid tracing configuration: %w", err)
+	}
+	// Export the spans before exiting
+	defer stopTracing(context.Background())
+
+	options, err := transport.DialOptions()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	// Creates a span per RPC with an event per message and sends the trace context to the server
+	options = append(options, grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithMessageEvents(otelgrpc.ReceivedEvents, otelgrpc.SentEvents))))
+	connection, err := grpc.Dial(transport.Address, options...)
 	if err != nil {
-		log.Fatalf("Could not create connection: %v", err)
+		return fmt.Errorf("could not create connection: %w", err)
 	}
 
 	// Close connection before exiting
@@ -154,22 +306,32 @@ func main() {
 
 	client := api.NewStudentsServiceClient(connection)
 
-	if len(os.Args) < 2 {
-		println(usage())
-		os.Exit(1)
-	}
+	// All RPCs of an example belong to one trace
+	ctx, span := otel.Tracer("github.com/simonhammes/301-cloud-computing-project/grpc/cmd/client").Start(context.Background(), flag.Arg(0))
+	defer span.End()
 
-	switch os.Args[1] {
+	switch flag.Arg(0) {
 	case "unary":
-		unaryExample(client)
+		err = unaryExample(ctx, client)
 	case "server-streaming":
-		serverStreamingExample(client)
+		err = serverStreamingExample(ctx, client)
 	case "client-streaming":
-		clientStreamingExample(client)
+		err = clientStreamingExample(ctx, client)
 	case "bidirectional":
-		bidirectionalStreamingExample(client)
+		err = bidirectionalStreamingExample(ctx, client)
+	case "crud":
+		err = crudExample(ctx, client)
+	case "courses":
+		err = coursesExample(ctx, api.NewCoursesServiceClient(connection))
+	case "enroll":
+		err = enrollExample(ctx, api.NewCoursesServiceClient(connection))
 	default:
-		println(usage())
-		os.Exit(1)
+		return errUsage
+	}
+	if err != nil {
+		// Mark the trace of the example as failed
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
 	}
+	return err
 }
@@ -0,0 +1,185 @@
+// Command devcerts generates a certificate authority and server and client certificates
+// for local development with TLS and mutual TLS. An existing CA in the output directory
+// is reused, so running it again rotates the server and client certificates, which the
+// server and client reload without a restart.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	out := flag.String("out", "certs", "output directory")
+	hosts := flag.String("hosts", "localhost,127.0.0.1,::1", "comma-separated DNS names and IP addresses of the server")
+	client := flag.String("client", "client", "common name of the client certificate")
+	validity := flag.Duration("validity", 30*24*time.Hour, "validity of the server and client certificates")
+	flag.Parse()
+
+	if err := generate(*out, strings.Split(*hosts, ","), *client, *validity); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Server: -tls-cert %[1]s -tls-key %[2]s -tls-client-ca %[3]s",
+		filepath.Join(*out, "server.pem"), filepath.Join(*out, "server-key.pem"), filepath.Join(*out, "ca.pem"))
+	log.Printf("Client: -tls-ca %[3]s -tls-cert %[1]s -tls-key %[2]s",
+		filepath.Join(*out, "client.pem"), filepath.Join(*out, "client-key.pem"), filepath.Join(*out, "ca.pem"))
+}
+
+// generate writes server and client certificates for hosts and client to out,
+// signed by the CA in out, which is created if it does not exist
+func generate(out string, hosts []string, client string, validity time.Duration) error {
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+
+	ca, caKey, err := loadOrCreateCA(out)
+	if err != nil {
+		return fmt.Errorf("failed to create CA: %w", err)
+	}
+
+	server := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			server.IPAddresses = append(server.IPAddresses, ip)
+		} else if host != "" {
+			server.DNSNames = append(server.DNSNames, host)
+		}
+	}
+	if err := issue(out, "server", server, validity, ca, caKey); err != nil {
+		return fmt.Errorf("failed to create server certificate: %w", err)
+	}
+
+	clientCertificate := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: client},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if err := issue(out, "client", clientCertificate, validity, ca, caKey); err != nil {
+		return fmt.Errorf("failed to create client certificate: %w", err)
+	}
+
+	return nil
+}
+
+// loadOrCreateCA returns the CA in dir, creating it if it does not exist
+func loadOrCreateCA(dir string) (*x509.Certificate, crypto.Signer, error) {
+	certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err == nil {
+		ca, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("Using existing CA %s", certFile)
+		return ca, pair.PrivateKey.(crypto.Signer), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Students development CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if err := setValidity(template, 10*365*24*time.Hour); err != nil {
+		return nil, nil, err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := write(certFile, keyFile, der, key); err != nil {
+		return nil, nil, err
+	}
+	log.Printf("Created CA %s", certFile)
+
+	ca, err := x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+// issue signs template with the CA and writes <name>.pem and <name>-key.pem to dir
+func issue(dir, name string, template *x509.Certificate, validity time.Duration, ca *x509.Certificate, caKey crypto.Signer) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if err := setValidity(template, validity); err != nil {
+		return err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return err
+	}
+
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	if err := write(certFile, keyFile, der, key); err != nil {
+		return err
+	}
+	log.Printf("Created %s (%s)", certFile, template.Subject.CommonName)
+
+	return nil
+}
+
+func setValidity(template *x509.Certificate, validity time.Duration) error {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	template.SerialNumber = serial
+	// Allow for clock skew
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(validity)
+	return nil
+}
+
+// write writes the certificate and key. The key is written first, so a reloading server
+// does not pick up a new certificate with an old key.
+func write(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := writePEM(keyFile, "PRIVATE KEY", keyDER, 0o600); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der, 0o644)
+}
+
+// writePEM replaces file atomically
+func writePEM(file, blockType string, der []byte, mode os.FileMode) error {
+	temp := file + ".tmp"
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(temp, data, mode); err != nil {
+		return err
+	}
+	return os.Rename(temp, file)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// load returns the key pair <name>.pem and <name>-key.pem in dir with the parsed certificate
+func load(t *testing.T, dir, name string) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	pair, err := tls.LoadX509KeyPair(filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem"))
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair(%s): %v", name, err)
+	}
+	certificate, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate(%s): %v", name, err)
+	}
+	return pair, certificate
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	if err := generate(dir, []string{"localhost", "127.0.0.1"}, "ada", time.Hour); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	_, ca := load(t, dir, "ca")
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	serverPair, server := load(t, dir, "server")
+	for _, host := range []string{"localhost", "127.0.0.1"} {
+		_, err := server.Verify(x509.VerifyOptions{DNSName: host, Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+		if err != nil {
+			t.Errorf("server certificate for %s: %v", host, err)
+		}
+	}
+
+	clientPair, client := load(t, dir, "client")
+	if _, err := client.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("client certificate: %v", err)
+	}
+	if client.Subject.CommonName != "ada" {
+		t.Errorf("client common name = %s, want ada", client.Subject.CommonName)
+	}
+
+	for _, name := range []string{"ca-key.pem", "server-key.pem", "client-key.pem"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Errorf("%s has mode %o, want 600", name, mode)
+		}
+	}
+
+	// The certificates work for mutual TLS
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tls.Server(serverConn, &tls.Config{
+			Certificates: []tls.Certificate{serverPair},
+			ClientCAs:    roots,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		}).Handshake()
+	}()
+	clientErr := tls.Client(clientConn, &tls.Config{
+		Certificates: []tls.Certificate{clientPair},
+		RootCAs:      roots,
+		ServerName:   "localhost",
+	}).Handshake()
+	if err := <-errs; err != nil || clientErr != nil {
+		t.Errorf("mutual TLS handshake: server %v, client %v", err, clientErr)
+	}
+}
+
+func TestGenerateReusesCA(t *testing.T) {
+	dir := t.TempDir()
+	if err := generate(dir, []string{"localhost"}, "client", time.Hour); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	_, ca := load(t, dir, "ca")
+	_, server := load(t, dir, "server")
+
+	if err := generate(dir, []string{"localhost"}, "client", time.Hour); err != nil {
+		t.Fatalf("generate again: %v", err)
+	}
+	_, rotatedCA := load(t, dir, "ca")
+	_, rotated := load(t, dir, "server")
+
+	if !bytes.Equal(rotatedCA.Raw, ca.Raw) {
+		t.Error("the CA was replaced")
+	}
+	if rotated.SerialNumber.Cmp(server.SerialNumber) == 0 {
+		t.Error("the server certificate was not rotated")
+	}
+	if err := rotated.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("rotated server certificate: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-faker/faker/v4"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Length of generated top-level arrays unless the request has a limit parameter.
+// Nested arrays have up to nestedItems items.
+const (
+	defaultItems = 3
+	nestedItems  = 2
+	maxItems     = 1000
+)
+
+// courseNames are used for name properties of Course objects
+var courseNames = []string{"Cloud Computing", "Databases", "Software Engineering", "Operating Systems", "Computer Networks", "IT Security"}
+
+// generator creates fake values for schemas. IDs are numbered per schema, starting at 1.
+type generator struct {
+	rand *rand.Rand
+	ids  map[string]int
+}
+
+// generate returns a JSON response body for mediaType. The fake data only depends on the
+// seed and the request, so a larger limit returns the same items followed by new ones.
+func (m *mock) generate(mediaType *openapi3.MediaType, operation *openapi3.Operation, r *http.Request) ([]byte, error) {
+	if mediaType.Example != nil {
+		return json.Marshal(mediaType.Example)
+	}
+
+	items := defaultItems
+	if parameter := operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, "limit"); parameter != nil {
+		// Validated against the document before
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+			items = min(limit, maxItems)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	faker.SetRandomSource(rand.NewSource(m.seed))
+	g := &generator{rand: rand.New(rand.NewSource(m.seed)), ids: make(map[string]int)}
+
+	value, err := g.value(mediaType.Schema, "", "", items)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// value generates a value for ref. object is the name of the enclosing component schema
+// and property the name of the property holding the value, both used to pick realistic data.
+// Arrays have the given number of items, or a random number if items is 0.
+func (g *generator) value(ref *openapi3.SchemaRef, object, property string, items int) (any, error) {
+	if ref.Ref != "" {
+		object = ref.Ref[strings.LastIndex(ref.Ref, "/")+1:]
+	}
+	schema := ref.Value
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example, nil
+	case len(schema.Enum) > 0:
+		return schema.Enum[g.rand.Intn(len(schema.Enum))], nil
+	}
+
+	switch schema.Type {
+	case openapi3.TypeObject, "":
+		// Sorted, so the same properties consume the same random numbers
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		result := make(map[string]any, len(schema.Properties))
+		for _, name := range names {
+			value, err := g.value(schema.Properties[name], object, name, 0)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = value
+		}
+		return result, nil
+	case openapi3.TypeArray:
+		n := g.arrayLength(schema, items)
+		result := make([]any, n)
+		for i := range result {
+			value, err := g.value(schema.Items, object, property, 0)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	case openapi3.TypeInteger:
+		if property == "id" {
+			g.ids[object]++
+			return g.ids[object], nil
+		}
+		return g.integer(schema), nil
+	case openapi3.TypeNumber:
+		return float64(g.integer(schema)) + g.rand.Float64(), nil
+	case openapi3.TypeBoolean:
+		return g.rand.Intn(2) == 0, nil
+	case openapi3.TypeString:
+		return g.string(object, property), nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type %q", schema.Type)
+	}
+}
+
+// arrayLength returns items, or a random length if items is 0, within the bounds of schema
+func (g *generator) arrayLength(schema *openapi3.Schema, items int) int {
+	n := items
+	if items == 0 {
+		n = g.rand.Intn(nestedItems + 1)
+	}
+	if n < int(schema.MinItems) {
+		n = int(schema.MinItems)
+	}
+	if schema.MaxItems != nil && n > int(*schema.MaxItems) {
+		n = int(*schema.MaxItems)
+	}
+	return n
+}
+
+func (g *generator) integer(schema *openapi3.Schema) int {
+	low, high := 0, 100
+	if schema.Min != nil {
+		low = int(*schema.Min)
+	}
+	if schema.Max != nil {
+		high = int(*schema.Max)
+	}
+	if high <= low {
+		return low
+	}
+	return low + g.rand.Intn(high-low+1)
+}
+
+func (g *generator) string(object, property string) string {
+	switch {
+	case object == "Student" && property == "name":
+		// Like the seed data of the gRPC server
+		return fmt.Sprintf("%s %s", faker.FirstName(), faker.LastName())
+	case object == "Course" && property == "name":
+		return courseNames[g.rand.Intn(len(courseNames))]
+	case property == "description":
+		return faker.Sentence()
+	case property == "name":
+		return faker.Name()
+	default:
+		return faker.Word()
+	}
+}
@@ -0,0 +1,178 @@
+// Command mockserver serves example responses for every path of an OpenAPI document,
+// so clients can be developed before the backend is finished. Requests are validated
+// against the document and responses are generated from the response schemas with
+// deterministic fake data.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/openapi"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ndjsonMediaType is used by the gateway for streamed requests and responses, one message per line
+const ndjsonMediaType = "application/x-ndjson"
+
+func init() {
+	openapi3filter.RegisterBodyDecoder(ndjsonMediaType, openapi.DecodeNDJSON)
+}
+
+type mock struct {
+	router routers.Router
+	seed   int64
+
+	// Fake data comes from the package-level source of faker
+	mu sync.Mutex
+}
+
+func main() {
+	specPath := flag.String("spec", "../swagger/students.yaml", "OpenAPI document to serve")
+	address := flag.String("listen", "127.0.0.1:3003", "listen address")
+	seed := flag.Int64("seed", 1, "seed of the fake data; equal requests get equal responses")
+	flag.Parse()
+
+	m, err := newMock(*specPath, *seed)
+	if err != nil {
+		log.Fatalf("Failed to load %s: %v", *specPath, err)
+	}
+
+	log.Printf("Serving mock of %s on %s", *specPath, *address)
+	if err := http.ListenAndServe(*address, m); err != nil {
+		log.Fatalf("Error starting mock server: %v", err)
+	}
+}
+
+// newMock returns a mock of the OpenAPI document at specPath
+func newMock(specPath string, seed int64) (*mock, error) {
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromFile(specPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	// Serve the paths at the root, regardless of the documented servers
+	spec.Servers = nil
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("could not create router: %w", err)
+	}
+
+	return &mock{router: router, seed: seed}, nil
+}
+
+func (m *mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, pathParams, err := m.router.FindRoute(r)
+	if err != nil {
+		if allowed := m.allowedMethods(r); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+	}
+	if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, response := successResponse(route.Operation)
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var body []byte
+	contentType, mediaType := responseMediaType(response)
+	if mediaType != nil && mediaType.Schema != nil {
+		body, err = m.generate(mediaType, route.Operation, r)
+		if err != nil {
+			log.Printf("Could not generate response for %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if contentType == ndjsonMediaType {
+			// A stream of a single message
+			body = append(body, '\n')
+		}
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	// The document is the contract: a response that violates it is a bug of the mock
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 code,
+		Header:                 w.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+	})
+	if err != nil {
+		log.Printf("Generated response for %s %s does not match the document: %v", r.Method, r.URL.Path, err)
+	}
+
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// allowedMethods returns the methods of the path of r. The router only reports
+// methods that are not allowed for paths without parameters.
+func (m *mock) allowedMethods(r *http.Request) []string {
+	var allowed []string
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		other := r.Clone(r.Context())
+		other.Method = method
+		if _, _, err := m.router.FindRoute(other); err == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// responseMediaType returns the JSON or, for streams, the NDJSON media type of response
+func responseMediaType(response *openapi3.Response) (string, *openapi3.MediaType) {
+	for _, contentType := range []string{"application/json", ndjsonMediaType} {
+		if mediaType := response.Content.Get(contentType); mediaType != nil {
+			return contentType, mediaType
+		}
+	}
+	return "", nil
+}
+
+// successResponse returns the lowest documented 2xx response of operation
+func successResponse(operation *openapi3.Operation) (int, *openapi3.Response) {
+	codes := make([]int, 0, len(operation.Responses))
+	for key := range operation.Responses {
+		code, err := strconv.Atoi(key)
+		if err == nil && code >= 200 && code < 300 {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return 0, nil
+	}
+	sort.Ints(codes)
+
+	return codes[0], operation.Responses.Get(codes[0]).Value
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The documents of the REST API and of the gateway
+const (
+	restSpec    = "../../../swagger/students.yaml"
+	gatewaySpec = "../../../swagger/gateway.yaml"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// startMock serves a mock of the document at specPath over an in-memory connection and returns a client of it
+func startMock(t *testing.T, specPath string, seed int64) *http.Client {
+	t.Helper()
+
+	m, err := newMock(specPath, seed)
+	if err != nil {
+		t.Fatalf("newMock(%s): %v", specPath, err)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := &http.Server{Handler: m}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		},
+	}}
+}
+
+func request(t *testing.T, client *http.Client, method, path, contentType, body string) (int, http.Header, string) {
+	t.Helper()
+
+	r, err := http.NewRequest(method, "http://mock"+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	response, err := client.Do(r)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("%s %s: reading body: %v", method, path, err)
+	}
+	return response.StatusCode, response.Header, string(data)
+}
+
+func TestFixedResponse(t *testing.T) {
+	const golden = "testdata/students.golden.json"
+	client := startMock(t, restSpec, 1)
+
+	code, header, body := request(t, client, http.MethodGet, "/students?limit=3", "", "")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", code, http.StatusOK, body)
+	}
+	if contentType := header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+
+	if *update {
+		if err := os.WriteFile(golden, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v, run go test -update to create it", err)
+	}
+	if body != string(want) {
+		t.Errorf("GET /students?limit=3 = %s, want (%s) %s", body, golden, want)
+	}
+}
+
+func TestDeterministicFixtures(t *testing.T) {
+	client := startMock(t, restSpec, 1)
+
+	_, _, first := request(t, client, http.MethodGet, "/students?limit=2", "", "")
+	_, _, again := request(t, client, http.MethodGet, "/students?limit=2", "", "")
+	if first != again {
+		t.Errorf("equal requests got different responses:\n%s\n%s", first, again)
+	}
+
+	// A larger limit returns the same students followed by new ones
+	_, _, more := request(t, client, http.MethodGet, "/students?limit=3", "", "")
+	if prefix := strings.TrimSuffix(first, "]"); !strings.HasPrefix(more, prefix+",") {
+		t.Errorf("limit=3 = %s, want to start with the students of limit=2 %s", more, first)
+	}
+
+	_, _, other := request(t, startMock(t, restSpec, 2), http.MethodGet, "/students?limit=2", "", "")
+	if other == first {
+		t.Errorf("seeds 1 and 2 got the same response %s", first)
+	}
+}
+
+type validationTest struct {
+	name        string
+	method      string
+	path        string
+	contentType string
+	body        string
+	code        int
+}
+
+func testValidation(t *testing.T, client *http.Client, tests []validationTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, _, body := request(t, client, test.method, test.path, test.contentType, test.body)
+			if code != test.code {
+				t.Errorf("%s %s = %d, want %d: %s", test.method, test.path, code, test.code, body)
+			}
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	testValidation(t, startMock(t, restSpec, 1), []validationTest{
+		{"limit", http.MethodGet, "/students?limit=1", "", "", http.StatusOK},
+		{"missing limit", http.MethodGet, "/students", "", "", http.StatusBadRequest},
+		{"limit below minimum", http.MethodGet, "/students?limit=0", "", "", http.StatusBadRequest},
+		{"limit not a number", http.MethodGet, "/students?limit=abc", "", "", http.StatusBadRequest},
+		{"unknown path", http.MethodGet, "/teachers", "", "", http.StatusNotFound},
+		{"method not allowed", http.MethodPost, "/students", "application/json", `{}`, http.StatusMethodNotAllowed},
+	})
+}
+
+func TestGatewayValidation(t *testing.T) {
+	testValidation(t, startMock(t, gatewaySpec, 1), []validationTest{
+		{"path parameter not a number", http.MethodGet, "/students/abc", "", "", http.StatusBadRequest},
+		{"page size above maximum", http.MethodGet, "/courses?page_size=1001", "", "", http.StatusBadRequest},
+		{"method not allowed", http.MethodPut, "/students/1", "application/json", `{}`, http.StatusMethodNotAllowed},
+		{"method not allowed without path parameters", http.MethodPut, "/courses", "application/json", `{}`, http.StatusMethodNotAllowed},
+		{"create", http.MethodPost, "/students", "application/json", `{"name": "Ada Lovelace"}`, http.StatusOK},
+		{"create with invalid property", http.MethodPost, "/students", "application/json", `{"name": 1}`, http.StatusBadRequest},
+		{"create without body", http.MethodPost, "/students", "application/json", "", http.StatusBadRequest},
+		{"import stream", http.MethodPost, "/students:import", ndjsonMediaType, "{\"students\": [{\"name\": \"Ada Lovelace\"}]}\n{\"students\": []}\n", http.StatusOK},
+		{"import stream with invalid message", http.MethodPost, "/students:import", ndjsonMediaType, "{\"students\": []}\n{\"students\": 1}\n", http.StatusBadRequest},
+	})
+}
+
+func TestStreamedResponse(t *testing.T) {
+	client := startMock(t, gatewaySpec, 1)
+
+	code, header, body := request(t, client, http.MethodGet, "/courses/1/students", "", "")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", code, http.StatusOK, body)
+	}
+	if contentType := header.Get("Content-Type"); contentType != ndjsonMediaType {
+		t.Errorf("Content-Type = %q, want %s", contentType, ndjsonMediaType)
+	}
+	if !strings.HasPrefix(body, `{"error":`) || strings.Count(body, "\n") != 1 || !strings.HasSuffix(body, "\n") {
+		t.Errorf("body = %q, want one JSON message per line", body)
+	}
+}
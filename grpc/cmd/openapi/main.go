@@ -0,0 +1,67 @@
+// Command openapi generates an OpenAPI document from api.proto and reports drift
+// against the hand-written swagger/gateway.yaml. It exits with status 1 on drift.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/openapi"
+	"log"
+	"os"
+)
+
+func main() {
+	specPath := flag.String("spec", "../swagger/gateway.yaml", "OpenAPI document to compare with, empty to skip")
+	out := flag.String("out", "", "write the generated document to this file (YAML), - for stdout")
+	flag.Parse()
+
+	generated, err := openapi.Generate("Students", "1.0.0", api.File_api_api_proto)
+	if err != nil {
+		log.Fatalf("Failed to generate OpenAPI document: %v", err)
+	}
+
+	if *out != "" {
+		if err := write(generated, *out); err != nil {
+			log.Fatalf("Failed to write OpenAPI document: %v", err)
+		}
+	}
+
+	if *specPath == "" {
+		return
+	}
+
+	spec, err := openapi3.NewLoader().LoadFromFile(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to load %s: %v", *specPath, err)
+	}
+
+	drift := openapi.Diff(generated, spec)
+	for _, line := range drift {
+		fmt.Println(line)
+	}
+	if len(drift) > 0 {
+		log.Printf("%s has drifted from api.proto (%d differences)", *specPath, len(drift))
+		os.Exit(1)
+	}
+}
+
+func write(doc *openapi3.T, path string) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	data, err = yaml.JSONToYAML(data)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
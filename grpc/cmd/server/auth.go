@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// publicMethods can be called without a token, e.g. by the health probes of orchestrators
+var publicMethods = map[string]bool{
+	"/grpc.health.v1.Health/Check": true,
+	"/grpc.health.v1.Health/Watch": true,
+}
+
+// authenticator rejects RPCs without a valid bearer token in the authorization metadata.
+// The claims of the token are available to handlers via auth.FromContext.
+// Rejections are written to the audit log.
+type authenticator struct {
+	verifier *auth.Verifier
+	audit    *slog.Logger
+}
+
+func (a *authenticator) unary(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, request)
+}
+
+func (a *authenticator) stream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate returns a context carrying the claims of the token and adds its subject to the logs
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	claims, err := a.verify(ctx)
+	if err != nil {
+		attrs := append(auditAttrs(ctx, method), slog.String("reason", status.Convert(err).Message()))
+		a.audit.LogAttrs(ctx, slog.LevelWarn, "Authentication failed", attrs...)
+		return nil, err
+	}
+
+	addLogAttrs(ctx, slog.String("subject", claims.Subject))
+	return auth.NewContext(ctx, claims), nil
+}
+
+// verify returns the claims of the bearer token in the authorization metadata
+func (a *authenticator) verify(ctx context.Context) (*auth.Claims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	scheme, token, _ := strings.Cut(values[0], " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+
+	claims, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	return claims, nil
+}
+
+// authenticatedStream carries the claims of the token in its context
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorizer rejects RPCs that none of the roles of the caller may call according to the policy.
+// It must run after the authenticator. Every decision is written to the audit log.
+type authorizer struct {
+	policy func() (*auth.Policy, error)
+	audit  *slog.Logger
+}
+
+func (a *authorizer) unary(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, request)
+}
+
+func (a *authorizer) stream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+func (a *authorizer) authorize(ctx context.Context, method string) error {
+	if publicMethods[method] {
+		return nil
+	}
+
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	policy, err := a.policy()
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not load authorization policy: %v", err)
+	}
+
+	role, allowed := policy.Allow(method, claims.Roles)
+
+	attrs := append(auditAttrs(ctx, method),
+		slog.String("subject", claims.Subject),
+		slog.Any("roles", claims.Roles),
+	)
+	if !allowed {
+		a.audit.LogAttrs(ctx, slog.LevelWarn, "Permission denied", attrs...)
+		return status.Errorf(codes.PermissionDenied, "none of the roles %v may call %s", claims.Roles, method)
+	}
+
+	a.audit.LogAttrs(ctx, slog.LevelInfo, "Permission granted", append(attrs, slog.String("role", role))...)
+	return nil
+}
+
+// auditAttrs returns the attributes of every audit record of an RPC
+func auditAttrs(ctx context.Context, method string) []slog.Attr {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return []slog.Attr{
+		slog.Bool("audit", true),
+		slog.String("method", method),
+		slog.String("peer", peerAddress(ctx, md)),
+		slog.String("request_id", requestID(ctx)),
+	}
+}
+
+// restMethods are the RPCs whose permissions apply to the requests of the REST API,
+// which reads the same data from the storage
+var restMethods = map[string]string{
+	"GET /students": "/StudentsService/GetStudents",
+}
+
+// protectREST authenticates and authorizes the requests of the REST API like RPCs, as it calls
+// the storage directly. authn and authz are nil if authentication or authorization are disabled.
+func protectREST(handler http.Handler, authn *authenticator, authz *authorizer) http.Handler {
+	if authn == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := restMethods[r.Method+" "+r.URL.Path]
+		if !ok {
+			// Unknown requests are only answered for authenticated callers, too,
+			// and only the patterns of the policy that match all methods apply
+			method = r.Method + " " + r.URL.Path
+		}
+
+		ctx, err := authn.authenticate(restContext(r, method), method)
+		if err != nil {
+			writeRESTError(w, err)
+			return
+		}
+		if authz != nil {
+			if err := authz.authorize(ctx, method); err != nil {
+				writeRESTError(w, err)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// restContext returns the context of a REST request with its credentials, request ID and
+// client address, as the interceptors expect them for an RPC
+func restContext(r *http.Request, method string) context.Context {
+	md := metadata.MD{}
+	for _, key := range []string{requestIDKey, "authorization"} {
+		if value := r.Header.Get(key); value != "" {
+			md.Set(key, value)
+		}
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	if address, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: address})
+	}
+
+	return rpcLogger(ctx, method)
+}
+
+func writeRESTError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	if s.Code() == codes.Unauthenticated {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	http.Error(w, s.Message(), runtime.HTTPStatusFromCode(s.Code()))
+}
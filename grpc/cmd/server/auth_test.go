@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/auth"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/rest"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIssuer signs tokens that its verifier accepts
+type testIssuer struct {
+	key      *ecdsa.PrivateKey
+	verifier *auth.Verifier
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "test", Algorithm: "ES256", Use: "sig"}}}
+	keys := auth.KeySetFunc(func(context.Context) (*jose.JSONWebKeySet, error) {
+		return set, nil
+	})
+	return &testIssuer{key: key, verifier: auth.NewVerifier(keys, "", "")}
+}
+
+// token returns a valid token of subject with roles
+func (i *testIssuer) token(t *testing.T, subject string, roles ...string) string {
+	t.Helper()
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: roles,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// testAudit collects audit records
+type testAudit struct {
+	logger *slog.Logger
+	buffer *bytes.Buffer
+}
+
+func newTestAudit() *testAudit {
+	var buffer bytes.Buffer
+	return &testAudit{logger: slog.New(slog.NewJSONHandler(&buffer, nil)), buffer: &buffer}
+}
+
+// records returns the records written since the last call
+func (a *testAudit) records(t *testing.T) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	decoder := json.NewDecoder(a.buffer)
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("audit record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// restStatus sends a request to handler and returns the status code
+func restStatus(t *testing.T, handler http.Handler, method, path, authorization string) (int, http.Header) {
+	t.Helper()
+	request := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder.Code, recorder.Header()
+}
+
+func TestProtectRESTAuthenticates(t *testing.T) {
+	issuer := newTestIssuer(t)
+	store := storage.NewMemory()
+	t.Cleanup(func() { store.Close() })
+	handler := protectREST(rest.NewHandler(store), &authenticator{verifier: issuer.verifier, audit: newTestAudit().logger}, nil)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"not a bearer token", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"invalid token", "Bearer invalid", http.StatusUnauthorized},
+		{"valid token", "Bearer " + issuer.token(t, "ada"), http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, header := restStatus(t, handler, http.MethodGet, "/students?limit=1", test.authorization)
+			if code != test.want {
+				t.Errorf("GET /students = %d, want %d", code, test.want)
+			}
+			if code == http.StatusUnauthorized && header.Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q, want Bearer", header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	t.Run("unknown request", func(t *testing.T) {
+		if code, _ := restStatus(t, handler, http.MethodDelete, "/students", ""); code != http.StatusUnauthorized {
+			t.Errorf("DELETE /students without token = %d, want %d", code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("authentication disabled", func(t *testing.T) {
+		if code, _ := restStatus(t, protectREST(rest.NewHandler(store), nil, nil), http.MethodGet, "/students?limit=1", ""); code != http.StatusOK {
+			t.Errorf("GET /students = %d, want %d", code, http.StatusOK)
+		}
+	})
+}
+
+func TestAuthenticateAuditsRejections(t *testing.T) {
+	issuer := newTestIssuer(t)
+	audit := newTestAudit()
+	authn := &authenticator{verifier: issuer.verifier, audit: audit.logger}
+	const method = "/StudentsService/GetStudentById"
+
+	tests := []struct {
+		name          string
+		authorization string
+		reason        string
+	}{
+		{"no token", "", "missing bearer token"},
+		{"not a bearer token", "Basic dXNlcjpwYXNz", "authorization must be a bearer token"},
+		{"invalid token", "Bearer invalid", "invalid token: "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if test.authorization != "" {
+				md.Set("authorization", test.authorization)
+			}
+			ctx := rpcLogger(metadata.NewIncomingContext(context.Background(), md), method)
+
+			_, err := authn.authenticate(ctx, method)
+			if status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("authenticate = %v, want Unauthenticated", err)
+			}
+
+			records := audit.records(t)
+			if len(records) != 1 {
+				t.Fatalf("%d audit records, want 1", len(records))
+			}
+			record := records[0]
+			reason, _ := record["reason"].(string)
+			if record["audit"] != true || record["method"] != method || record["request_id"] != requestID(ctx) || !strings.HasPrefix(reason, test.reason) {
+				t.Errorf("audit record = %v, want method %s and reason %q", record, method, test.reason)
+			}
+		})
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		md := metadata.Pairs("authorization", "Bearer "+issuer.token(t, "ada"))
+		if _, err := authn.authenticate(metadata.NewIncomingContext(context.Background(), md), method); err != nil {
+			t.Fatalf("authenticate: %v", err)
+		}
+		// Granted permissions are audited by the authorizer
+		if records := audit.records(t); len(records) != 0 {
+			t.Errorf("audit records = %v, want none", records)
+		}
+	})
+
+	t.Run("public method", func(t *testing.T) {
+		if _, err := authn.authenticate(context.Background(), "/grpc.health.v1.Health/Check"); err != nil {
+			t.Fatalf("authenticate: %v", err)
+		}
+		if records := audit.records(t); len(records) != 0 {
+			t.Errorf("audit records = %v, want none", records)
+		}
+	})
+}
+
+func TestProtectRESTAuthorizes(t *testing.T) {
+	issuer := newTestIssuer(t)
+	store := storage.NewMemory()
+	t.Cleanup(func() { store.Close() })
+	policy, err := auth.ParsePolicy([]byte(`
+roles:
+  reader:
+    - /StudentsService/GetStudents
+  admin:
+    - "*"
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	audit := newTestAudit()
+	authn := &authenticator{verifier: issuer.verifier, audit: audit.logger}
+	authz := &authorizer{policy: func() (*auth.Policy, error) { return policy, nil }, audit: audit.logger}
+	handler := protectREST(rest.NewHandler(store), authn, authz)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		roles   []string
+		want    int
+		message string
+	}{
+		{"reader", http.MethodGet, "/students?limit=1", []string{"reader"}, http.StatusOK, "Permission granted"},
+		{"no role", http.MethodGet, "/students?limit=1", nil, http.StatusForbidden, "Permission denied"},
+		{"unknown role", http.MethodGet, "/students?limit=1", []string{"writer"}, http.StatusForbidden, "Permission denied"},
+		{"reader, unknown request", http.MethodDelete, "/students", []string{"reader"}, http.StatusForbidden, "Permission denied"},
+		{"admin, unknown request", http.MethodDelete, "/students", []string{"admin"}, http.StatusMethodNotAllowed, "Permission granted"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, _ := restStatus(t, handler, test.method, test.path, "Bearer "+issuer.token(t, "ada", test.roles...))
+			if code != test.want {
+				t.Errorf("%s %s = %d, want %d", test.method, test.path, code, test.want)
+			}
+
+			records := audit.records(t)
+			if len(records) != 1 || records[0]["msg"] != test.message || records[0]["subject"] != "ada" {
+				t.Errorf("audit records = %v, want %q for ada", records, test.message)
+			}
+		})
+	}
+
+	t.Run("no token", func(t *testing.T) {
+		if code, _ := restStatus(t, handler, http.MethodGet, "/students?limit=1", ""); code != http.StatusUnauthorized {
+			t.Errorf("GET /students = %d, want %d", code, http.StatusUnauthorized)
+		}
+		records := audit.records(t)
+		if len(records) != 1 || records[0]["msg"] != "Authentication failed" || records[0]["method"] != "/StudentsService/GetStudents" {
+			t.Errorf("audit records = %v, want a failed authentication of GetStudents", records)
+		}
+	})
+}
+
+func TestAuthorizePublicMethods(t *testing.T) {
+	audit := newTestAudit()
+	authz := &authorizer{
+		policy: func() (*auth.Policy, error) { return auth.ParsePolicy([]byte("roles: {}\n")) },
+		audit:  audit.logger,
+	}
+
+	for method := range publicMethods {
+		// Health probes have no token and no role
+		if err := authz.authorize(context.Background(), method); err != nil {
+			t.Errorf("authorize(%s) = %v, want no error", method, err)
+		}
+	}
+	if err := authz.authorize(context.Background(), "/StudentsService/GetStudents"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("authorize without claims = %v, want Unauthenticated", err)
+	}
+
+	ctx := auth.NewContext(context.Background(), &auth.Claims{Roles: []string{"admin"}})
+	if err := authz.authorize(ctx, "/StudentsService/GetStudents"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("authorize with an empty policy = %v, want PermissionDenied", err)
+	}
+	if records := audit.records(t); len(records) != 1 || records[0]["msg"] != "Permission denied" {
+		t.Errorf("audit records = %v, want a denial", records)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// bridge exposes streaming RPCs to clients without a gRPC stack. Messages are JSON objects
+// with the same encoding as the gateway.
+//
+// Every message is only received from the gRPC stream after the previous one was written
+// to the client, so slow clients slow down the stream. Disconnecting cancels the RPC.
+type bridge struct {
+	students api.StudentsServiceClient
+	upgrader *websocket.Upgrader
+}
+
+// newBridge returns a bridge to students. Browsers may open WebSockets from the same origin
+// and from the allowed origins of the -cors flag, like they may call the gRPC-Web handler.
+func newBridge(students api.StudentsServiceClient, allowedOrigins []string) *bridge {
+	allowed := originAllowed(allowedOrigins)
+	upgrader := &websocket.Upgrader{CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowed(origin) {
+			// Not a browser or an allowed origin
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}}
+	return &bridge{students: students, upgrader: upgrader}
+}
+
+// getStudents streams GetStudents as Server-Sent Events. The request fields are taken from
+// the query string, e.g. ?per_message=5&page_size=20. Every response is a message event
+// with the next page token as its ID, so EventSource resumes after the last page when it
+// reconnects. The stream ends with an end event, or an error event holding a google.rpc.Status.
+func (b *bridge) getStudents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	request := &api.GetStudentsRequest{}
+	if err := runtime.PopulateQueryParameters(request, r.URL.Query(), utilities.NewDoubleArray(nil)); err != nil {
+		writeStatus(w, status.Errorf(codes.InvalidArgument, "%v", err))
+		return
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		request.PageToken = id
+	}
+
+	stream, err := b.students.GetStudents(forwardedContext(r), request)
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+
+	started := false
+	for {
+		response, err := stream.Recv()
+		if err != nil && err != io.EOF && !started {
+			// Nothing was sent yet, so errors like invalid arguments get a proper HTTP status
+			writeStatus(w, err)
+			return
+		}
+		// An empty listing, e.g. when resuming after the last page, only has the end event
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		if err == io.EOF {
+			writeEvent(w, "end", "", []byte("{}"))
+			flusher.Flush()
+			return
+		}
+		if err != nil {
+			data, _ := marshalOptions.Marshal(status.Convert(err).Proto())
+			writeEvent(w, "error", "", data)
+			flusher.Flush()
+			return
+		}
+
+		data, err := marshalOptions.Marshal(response)
+		if err != nil {
+			log.Printf("Could not marshal response: %v", err)
+			return
+		}
+		if err := writeEvent(w, "message", response.NextPageToken, data); err != nil {
+			// The client is gone, returning cancels the RPC
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a Server-Sent Event. data must not contain newlines, which holds for protojson output.
+func writeEvent(w io.Writer, event, id string, data []byte) error {
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// writeStatus writes a gRPC error as a google.rpc.Status with the matching HTTP status code
+func writeStatus(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	data, _ := marshalOptions.Marshal(s.Proto())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(runtime.HTTPStatusFromCode(s.Code()))
+	w.Write(data)
+}
+
+// importStudentsV2 bridges ImportStudentsV2 to a WebSocket. Every text message from the client
+// is an ImportStudentsV2Request and every message from the server an ImportStudentsV2Response.
+// A close frame from the client ends the request stream; the server sends the remaining
+// responses and closes the connection. Errors are sent as {"error": google.rpc.Status}
+// before closing.
+func (b *bridge) importStudentsV2(w http.ResponseWriter, r *http.Request) {
+	conn, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has responded already
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancelCause(forwardedContext(r))
+	defer cancel(nil)
+
+	stream, err := b.students.ImportStudentsV2(ctx)
+	if err != nil {
+		closeWebSocket(conn, err)
+		return
+	}
+
+	// Do not answer a close frame right away, responses may still be pending
+	closed := errors.New("client closed connection")
+	conn.SetCloseHandler(func(code int, text string) error {
+		return nil
+	})
+
+	go func() {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				stream.CloseSend()
+				return
+			}
+			if err != nil {
+				cancel(closed)
+				return
+			}
+
+			if messageType != websocket.TextMessage {
+				cancel(status.Error(codes.InvalidArgument, "expected text messages"))
+				return
+			}
+			request := &api.ImportStudentsV2Request{}
+			if err := unmarshalOptions.Unmarshal(data, request); err != nil {
+				cancel(status.Errorf(codes.InvalidArgument, "invalid request: %v", err))
+				return
+			}
+			if err := stream.Send(request); err != nil {
+				// The actual error is returned by Recv
+				return
+			}
+		}
+	}()
+
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			closeWebSocket(conn, nil)
+			return
+		}
+		if err != nil {
+			if cause := context.Cause(ctx); cause == closed {
+				return
+			} else if cause != nil {
+				err = cause
+			}
+			closeWebSocket(conn, err)
+			return
+		}
+
+		data, err := marshalOptions.Marshal(response)
+		if err != nil {
+			log.Printf("Could not marshal response: %v", err)
+			closeWebSocket(conn, status.Error(codes.Internal, "internal error"))
+			return
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return
+		}
+	}
+}
+
+// closeWebSocket sends err, if any, and a close frame
+func closeWebSocket(conn *websocket.Conn, err error) {
+	code := websocket.CloseNormalClosure
+	if err != nil {
+		s := status.Convert(err)
+		data, _ := marshalOptions.Marshal(s.Proto())
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"error":%s}`, data)))
+
+		code = websocket.CloseInternalServerErr
+		if s.Code() == codes.InvalidArgument {
+			code = websocket.ClosePolicyViolation
+		}
+	}
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""))
+}
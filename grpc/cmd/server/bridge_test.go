@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeStudentsClient returns a stream of the given responses followed by err from GetStudents
+type fakeStudentsClient struct {
+	api.StudentsServiceClient
+	responses []*api.GetStudentsResponse
+	err       error
+	request   *api.GetStudentsRequest
+}
+
+func (c *fakeStudentsClient) GetStudents(_ context.Context, request *api.GetStudentsRequest, _ ...grpc.CallOption) (api.StudentsService_GetStudentsClient, error) {
+	c.request = request
+	return &fakeGetStudentsStream{client: c}, nil
+}
+
+type fakeGetStudentsStream struct {
+	grpc.ClientStream
+	client *fakeStudentsClient
+}
+
+func (s *fakeGetStudentsStream) Recv() (*api.GetStudentsResponse, error) {
+	if len(s.client.responses) == 0 {
+		return nil, s.client.err
+	}
+	response := s.client.responses[0]
+	s.client.responses = s.client.responses[1:]
+	return response, nil
+}
+
+// event is a Server-Sent Event
+type event struct {
+	id, name, data string
+}
+
+// parseEvents parses a stream of events written by writeEvent
+func parseEvents(t *testing.T, body string) []event {
+	t.Helper()
+	if !strings.HasSuffix(body, "\n\n") {
+		t.Fatalf("body = %q, want events ending with an empty line", body)
+	}
+
+	var events []event
+	for _, block := range strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n") {
+		var e event
+		for _, line := range strings.Split(block, "\n") {
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				e.id = value
+			case "event":
+				e.name = value
+			case "data":
+				e.data = value
+			default:
+				t.Fatalf("unexpected line %q in %q", line, body)
+			}
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// equalJSON reports whether a and b are equal JSON values. protojson output varies in whitespace.
+func equalJSON(a, b string) bool {
+	var x, y any
+	if json.Unmarshal([]byte(a), &x) != nil || json.Unmarshal([]byte(b), &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func TestGetStudentsEvents(t *testing.T) {
+	students := []*api.Student{{Id: 1, Name: "Ada Lovelace"}}
+
+	tests := []struct {
+		name        string
+		client      *fakeStudentsClient
+		code        int
+		contentType string
+		events      []event
+		// JSON body of responses that are not an event stream
+		body string
+	}{
+		{
+			name:        "empty listing",
+			client:      &fakeStudentsClient{err: io.EOF},
+			code:        http.StatusOK,
+			contentType: "text/event-stream",
+			events:      []event{{name: "end", data: `{}`}},
+		},
+		{
+			name: "pages",
+			client: &fakeStudentsClient{responses: []*api.GetStudentsResponse{
+				{Students: students, NextPageToken: "next"},
+				{Students: students},
+			}, err: io.EOF},
+			code:        http.StatusOK,
+			contentType: "text/event-stream",
+			events: []event{
+				{id: "next", name: "message", data: `{"students": [{"id": 1, "name": "Ada Lovelace", "courses": []}], "next_page_token": "next"}`},
+				{name: "message", data: `{"students": [{"id": 1, "name": "Ada Lovelace", "courses": []}], "next_page_token": ""}`},
+				{name: "end", data: `{}`},
+			},
+		},
+		{
+			name:        "error before the first message",
+			client:      &fakeStudentsClient{err: status.Error(codes.InvalidArgument, "invalid page token")},
+			code:        http.StatusBadRequest,
+			contentType: "application/json",
+			body:        `{"code":3,"message":"invalid page token","details":[]}`,
+		},
+		{
+			name:        "error after the first message",
+			client:      &fakeStudentsClient{responses: []*api.GetStudentsResponse{{}}, err: status.Error(codes.Internal, "storage failed")},
+			code:        http.StatusOK,
+			contentType: "text/event-stream",
+			events: []event{
+				{name: "message", data: `{"students": [], "next_page_token": ""}`},
+				{name: "error", data: `{"code": 13, "message": "storage failed", "details": []}`},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &bridge{students: test.client}
+			recorder := httptest.NewRecorder()
+			b.getStudents(recorder, httptest.NewRequest(http.MethodGet, "/v1/students:sse", nil))
+
+			if recorder.Code != test.code {
+				t.Errorf("status = %d, want %d", recorder.Code, test.code)
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != test.contentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, test.contentType)
+			}
+			if test.events == nil {
+				if !equalJSON(recorder.Body.String(), test.body) {
+					t.Errorf("body = %s, want %s", recorder.Body, test.body)
+				}
+				return
+			}
+			events := parseEvents(t, recorder.Body.String())
+			if len(events) != len(test.events) {
+				t.Fatalf("events = %v, want %v", events, test.events)
+			}
+			for i, e := range events {
+				want := test.events[i]
+				if e.id != want.id || e.name != want.name || !equalJSON(e.data, want.data) {
+					t.Errorf("event %d = %+v, want %+v", i, e, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetStudentsEventsResume(t *testing.T) {
+	client := &fakeStudentsClient{err: io.EOF}
+	b := &bridge{students: client}
+
+	// EventSource sends the ID of the last event when it reconnects
+	request := httptest.NewRequest(http.MethodGet, "/v1/students:sse?per_message=5&page_token=old", nil)
+	request.Header.Set("Last-Event-ID", "last")
+	recorder := httptest.NewRecorder()
+	b.getStudents(recorder, request)
+
+	if client.request.PageToken != "last" || client.request.PerMessage != 5 {
+		t.Errorf("request = %v, want page_token last and per_message 5", client.request)
+	}
+	if recorder.Code != http.StatusOK || !strings.HasSuffix(recorder.Body.String(), "event: end\ndata: {}\n\n") {
+		t.Errorf("response = %d %q, want an end event", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestImportStudentsV2WebSocket(t *testing.T) {
+	s := startTestServer(t, grpc.ChainStreamInterceptor(validateStream))
+	url := "ws" + strings.TrimPrefix(s.gateway.URL, "http") + "/v1/students:importV2:ws"
+
+	dial := func(t *testing.T) *websocket.Conn {
+		t.Helper()
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	t.Run("import", func(t *testing.T) {
+		conn := dial(t)
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"students": [{"name": "Grace Hopper"}]}`)); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage: %v", err)
+		}
+		var response api.ImportStudentsV2Response
+		if err := unmarshalOptions.Unmarshal(data, &response); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+		if len(response.Students) != 1 || response.Students[0].Id == 0 || response.Students[0].Name != "Grace Hopper" {
+			t.Errorf("response = %s, want Grace Hopper with an ID", data)
+		}
+
+		// Closing the request stream ends the RPC, then the server closes the connection
+		// The default handler fails to answer the server's close frame after ours
+		conn.SetCloseHandler(func(code int, text string) error {
+			return &websocket.CloseError{Code: code, Text: text}
+		})
+		if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+		if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			t.Errorf("ReadMessage after closing = %v, want a normal closure", err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"invalid JSON", `{"students": 1}`},
+		{"invalid student", `{"students": [{"name": ""}]}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := dial(t)
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(test.message)); err != nil {
+				t.Fatalf("WriteMessage: %v", err)
+			}
+
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage: %v", err)
+			}
+			var message struct {
+				Error struct {
+					Code int `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(data, &message); err != nil || codes.Code(message.Error.Code) != codes.InvalidArgument {
+				t.Errorf("message = %s, want an InvalidArgument error", data)
+			}
+			if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Errorf("ReadMessage after the error = %v, want a policy violation", err)
+			}
+		})
+	}
+}
+
+func TestWebSocketOrigins(t *testing.T) {
+	b := newBridge(nil, []string{"https://app.example.com"})
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "", true},
+		{"same origin", "https://gateway.example.com", true},
+		{"allowed origin", "https://app.example.com", true},
+		{"other origin", "https://evil.example.com", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "https://gateway.example.com/v1/students:importV2:ws", nil)
+			if test.origin != "" {
+				request.Header.Set("Origin", test.origin)
+			}
+			if got := b.upgrader.CheckOrigin(request); got != test.want {
+				t.Errorf("CheckOrigin(%q) = %v, want %v", test.origin, got, test.want)
+			}
+		})
+	}
+
+	t.Run("any origin", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "https://gateway.example.com/v1/students:importV2:ws", nil)
+		request.Header.Set("Origin", "https://evil.example.com")
+		if !newBridge(nil, []string{"*"}).upgrader.CheckOrigin(request) {
+			t.Error("CheckOrigin with * = false, want true")
+		}
+	})
+}
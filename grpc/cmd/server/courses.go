@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// defaultPageSize is used if ListCoursesRequest.page_size is not set
+const defaultPageSize = 50
+
+type coursesServer struct {
+	api.CoursesServiceServer
+	store storage.Store
+}
+
+func (s *coursesServer) GetCourse(ctx context.Context, request *api.GetCourseRequest) (*api.Course, error) {
+	course, err := s.store.GetCourse(ctx, request.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "course %d not found", request.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not get course: %v", err)
+	}
+
+	return course, nil
+}
+
+func (s *coursesServer) ListCourses(ctx context.Context, request *api.ListCoursesRequest) (*api.ListCoursesResponse, error) {
+	afterID, err := decodePageToken(request.PageToken)
+	if err != nil {
+		return nil, api.InvalidArgument("page_token", "is not a token returned by a previous response")
+	}
+
+	pageSize := int(request.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	// Fetch one additional course to find out whether there are more
+	courses, err := s.store.ListCourses(ctx, afterID, pageSize+1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not list courses: %v", err)
+	}
+
+	response := &api.ListCoursesResponse{Courses: courses}
+	if len(courses) > pageSize {
+		response.Courses = courses[:pageSize]
+		response.NextPageToken = encodePageToken(courses[pageSize-1].Id)
+	}
+
+	return response, nil
+}
+
+func (s *coursesServer) CreateCourse(ctx context.Context, request *api.CreateCourseRequest) (*api.Course, error) {
+	course := request.Course
+	if err := s.store.CreateCourse(ctx, course); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not create course: %v", err)
+	}
+
+	return course, nil
+}
+
+func (s *coursesServer) UpdateCourse(ctx context.Context, request *api.UpdateCourseRequest) (*api.Course, error) {
+	fields, err := request.UpdatedFields()
+	if err != nil {
+		return nil, err
+	}
+
+	course, err := s.store.UpdateCourse(ctx, request.Course.Id, func(course *api.Course) error {
+		api.CopyFields(course, request.Course, fields)
+		return nil
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "course %d not found", request.Course.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not update course: %v", err)
+	}
+
+	return course, nil
+}
+
+func (s *coursesServer) DeleteCourse(ctx context.Context, request *api.DeleteCourseRequest) (*emptypb.Empty, error) {
+	err := s.store.DeleteCourse(ctx, request.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "course %d not found", request.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not delete course: %v", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *coursesServer) Enroll(ctx context.Context, request *api.EnrollRequest) (*emptypb.Empty, error) {
+	err := s.store.Enroll(ctx, request.StudentId, request.CourseId)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil, status.Errorf(codes.NotFound, "student %d or course %d not found", request.StudentId, request.CourseId)
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return nil, status.Errorf(codes.AlreadyExists, "student %d is already enrolled in course %d", request.StudentId, request.CourseId)
+	case errors.Is(err, storage.ErrCourseFull):
+		return nil, status.Errorf(codes.FailedPrecondition, "course %d is full", request.CourseId)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "could not enroll student: %v", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *coursesServer) Unenroll(ctx context.Context, request *api.UnenrollRequest) (*emptypb.Empty, error) {
+	err := s.store.Unenroll(ctx, request.StudentId, request.CourseId)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "student %d is not enrolled in course %d", request.StudentId, request.CourseId)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not unenroll student: %v", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *coursesServer) ListStudentCourses(ctx context.Context, request *api.ListStudentCoursesRequest) (*api.ListStudentCoursesResponse, error) {
+	courses, err := s.store.ListStudentCourses(ctx, request.StudentId)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "student %d not found", request.StudentId)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not list courses: %v", err)
+	}
+
+	return &api.ListStudentCoursesResponse{Courses: courses}, nil
+}
+
+func (s *coursesServer) GetCourseStudents(request *api.GetCourseStudentsRequest, stream api.CoursesService_GetCourseStudentsServer) error {
+	ctx := stream.Context()
+	_, err := s.store.GetCourse(ctx, request.CourseId)
+	if errors.Is(err, storage.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "course %d not found", request.CourseId)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not get course: %v", err)
+	}
+
+	list := func(ctx context.Context, afterID int32, limit int) ([]*api.Student, error) {
+		return s.store.ListCourseStudents(ctx, request.CourseId, afterID, limit)
+	}
+
+	return streamStudents(ctx, stream.Send, list, request.PerMessage, request.PageSize, request.PageToken)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// JSON encoding of the gateway and the streaming bridges.
+// Use the field names from api.proto, e.g. next_page_token
+var (
+	marshalOptions = protojson.MarshalOptions{
+		UseProtoNames:   true,
+		EmitUnpopulated: true,
+	}
+	unmarshalOptions = protojson.UnmarshalOptions{
+		DiscardUnknown: true,
+	}
+)
+
+// newGateway returns a handler that translates JSON/HTTP requests according to the
+// google.api.http annotations in api.proto and forwards them to the gRPC server reached by dial.
+// Streaming responses are written as newline-delimited JSON, one {"result": ...} object per message.
+// The handler also serves the Server-Sent Events and WebSocket bridges, see bridge.go.
+// allowedOrigins are those of the -cors flag.
+func newGateway(ctx context.Context, dial dialer, allowedOrigins []string) (http.Handler, error) {
+	conn, err := grpc.DialContext(ctx, "passthrough:///internal",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return dial(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	gateway := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &gatewayMarshaler{JSONPb: runtime.JSONPb{
+			MarshalOptions:   marshalOptions,
+			UnmarshalOptions: unmarshalOptions,
+		}}),
+		runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			// The request ID and the trace context of the caller
+			switch key := strings.ToLower(key); key {
+			case requestIDKey, "traceparent", "tracestate", "baggage":
+				return key, true
+			}
+			return runtime.DefaultHeaderMatcher(key)
+		}),
+	)
+
+	if err := api.RegisterStudentsServiceHandler(ctx, gateway, conn); err != nil {
+		return nil, err
+	}
+	if err := api.RegisterCoursesServiceHandler(ctx, gateway, conn); err != nil {
+		return nil, err
+	}
+
+	bridge := newBridge(api.NewStudentsServiceClient(conn), allowedOrigins)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/students:sse", bridge.getStudents)
+	mux.HandleFunc("/v1/students:importV2:ws", bridge.importStudentsV2)
+	mux.Handle("/", gateway)
+
+	return mux, nil
+}
+
+// ndjsonMediaType labels the streamed responses of the gateway, one JSON object per line
+const ndjsonMediaType = "application/x-ndjson"
+
+// streamedMessages are the full names of the messages that server-streaming methods return
+var streamedMessages = func() map[protoreflect.FullName]bool {
+	names := map[protoreflect.FullName]bool{}
+	services := api.File_api_api_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			if methods.Get(j).IsStreamingServer() {
+				names[methods.Get(j).Output().FullName()] = true
+			}
+		}
+	}
+	return names
+}()
+
+// gatewayMarshaler is the JSON marshaler of the gateway. The gateway asks it for the
+// content type of every response, so it returns ndjsonMediaType for streamed messages.
+type gatewayMarshaler struct {
+	runtime.JSONPb
+}
+
+func (m *gatewayMarshaler) ContentType(v any) string {
+	if message, ok := v.(proto.Message); ok && streamedMessages[message.ProtoReflect().Descriptor().FullName()] {
+		return ndjsonMediaType
+	}
+	return m.JSONPb.ContentType(v)
+}
+
+// dialer opens a connection to the gRPC server
+type dialer func(ctx context.Context) (net.Conn, error)
+
+// serveGateway serves the JSON/HTTP gateway in the background, with TLS if tlsConfig is not nil
+func serveGateway(address string, dial dialer, allowedOrigins []string, tlsConfig *tls.Config) *http.Server {
+	gateway, err := newGateway(context.Background(), dial, allowedOrigins)
+	if err != nil {
+		log.Fatalf("Error creating gateway: %v", err)
+	}
+
+	return startHTTP("JSON/HTTP gateway", address, gateway, tlsConfig)
+}
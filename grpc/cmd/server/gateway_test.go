@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/openapi"
+	"google.golang.org/grpc"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const gatewaySpec = "../../../swagger/gateway.yaml"
+
+func init() {
+	openapi3filter.RegisterBodyDecoder(ndjsonMediaType, openapi.DecodeNDJSON)
+}
+
+// gatewayContract sends requests to the gateway of a test server and validates both sides against gateway.yaml
+type gatewayContract struct {
+	t      *testing.T
+	spec   *openapi3.T
+	router routers.Router
+	client *http.Client
+	// Operations that were called, e.g. "GET /students/{id}"
+	called map[string]bool
+}
+
+func newGatewayContract(t *testing.T) *gatewayContract {
+	t.Helper()
+
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromFile(gatewaySpec)
+	if err != nil {
+		t.Fatalf("LoadFromFile(%s): %v", gatewaySpec, err)
+	}
+	if err := spec.Validate(loader.Context); err != nil {
+		t.Fatalf("%s is not a valid OpenAPI document: %v", gatewaySpec, err)
+	}
+
+	s := startTestServer(t, grpc.ChainUnaryInterceptor(validateUnary))
+
+	// Route requests to the test server instead of the documented ones
+	spec.Servers = openapi3.Servers{{URL: s.gateway.URL + "/v1"}}
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	return &gatewayContract{t: t, spec: spec, router: router, client: s.gateway.Client(), called: map[string]bool{}}
+}
+
+// call sends a request that gateway.yaml must accept and checks the response against the document.
+// It returns the status code and the body of the response.
+func (c *gatewayContract) call(method, path, contentType, body string) (int, string) {
+	c.t.Helper()
+
+	request, err := http.NewRequest(method, c.spec.Servers[0].URL+path, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatalf("NewRequest: %v", err)
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	route, pathParams, err := c.router.FindRoute(request)
+	if err != nil {
+		c.t.Fatalf("%s %s is not in %s: %v", method, path, gatewaySpec, err)
+	}
+	c.called[method+" "+route.Path] = true
+	input := &openapi3filter.RequestValidationInput{
+		Request:    request,
+		PathParams: pathParams,
+		Route:      route,
+	}
+	if err := openapi3filter.ValidateRequest(context.Background(), input); err != nil {
+		c.t.Fatalf("%s %s: request does not match %s: %v", method, path, gatewaySpec, err)
+	}
+	// Validation consumed the body
+	request.Body = io.NopCloser(strings.NewReader(body))
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		c.t.Fatalf("%s %s: reading body: %v", method, path, err)
+	}
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 response.StatusCode,
+		Header:                 response.Header,
+		Body:                   io.NopCloser(bytes.NewReader(responseBody)),
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+	if err != nil {
+		c.t.Errorf("%s %s: response does not match %s: %v", method, path, gatewaySpec, err)
+	}
+
+	return response.StatusCode, string(responseBody)
+}
+
+func TestGatewayContract(t *testing.T) {
+	c := newGatewayContract(t)
+
+	// The test server has student 1 and course 1, requests depend on the ones before
+	tests := []struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{http.MethodGet, "/students?per_message=1&page_size=1", "", "", http.StatusOK},
+		{http.MethodPost, "/students", "application/json", `{"name": "Grace Hopper"}`, http.StatusOK},
+		{http.MethodGet, "/students/2", "", "", http.StatusOK},
+		{http.MethodGet, "/students/99", "", "", http.StatusNotFound},
+		{http.MethodPatch, "/students/2?update_mask=name", "application/json", `{"name": "Grace Brewster Hopper"}`, http.StatusOK},
+		{http.MethodPost, "/students:import", ndjsonMediaType, "{\"students\": [{\"name\": \"Alan Turing\"}]}\n", http.StatusOK},
+		{http.MethodPost, "/students:importV2", ndjsonMediaType, "{\"students\": [{\"name\": \"Edsger Dijkstra\"}]}\n", http.StatusOK},
+		{http.MethodPost, "/courses", "application/json", `{"name": "Distributed Systems", "capacity": 10}`, http.StatusOK},
+		{http.MethodGet, "/courses?page_size=1", "", "", http.StatusOK},
+		{http.MethodGet, "/courses/2", "", "", http.StatusOK},
+		{http.MethodPatch, "/courses/2?update_mask=description", "application/json", `{"description": "Consensus and replication"}`, http.StatusOK},
+		{http.MethodPost, "/courses/1/students", "application/json", `{"student_id": 2}`, http.StatusOK},
+		{http.MethodGet, "/students/2/courses", "", "", http.StatusOK},
+		{http.MethodGet, "/courses/1/students", "", "", http.StatusOK},
+		{http.MethodDelete, "/courses/1/students/2", "", "", http.StatusOK},
+		{http.MethodDelete, "/courses/2", "", "", http.StatusOK},
+		{http.MethodDelete, "/students/2", "", "", http.StatusOK},
+	}
+	for _, test := range tests {
+		code, body := c.call(test.method, test.path, test.contentType, test.body)
+		if code != test.want {
+			t.Errorf("%s %s = %d, want %d: %s", test.method, test.path, code, test.want, body)
+		}
+	}
+
+	for path, item := range c.spec.Paths {
+		for method := range item.Operations() {
+			if !c.called[method+" "+path] {
+				t.Errorf("%s %s is not tested", method, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"log"
+	"sync"
+	"time"
+)
+
+// probeTimeout is the time a storage probe may take before the service is reported as not serving
+const probeTimeout = 2 * time.Second
+
+// probe checks whether the storage a service depends on is reachable
+type probe func(ctx context.Context) error
+
+// healthService reports the status of each service, which depends on the storage being reachable.
+// The overall status (service "") is SERVING when all services are.
+type healthService struct {
+	*health.Server
+	probes   map[string]probe
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+func newHealthService(store storage.Store) *healthService {
+	h := &healthService{
+		Server: health.NewServer(),
+		probes: map[string]probe{
+			api.StudentsService_ServiceDesc.ServiceName: func(ctx context.Context) error {
+				_, err := store.ListStudents(ctx, 0, 1)
+				return err
+			},
+			api.CoursesService_ServiceDesc.ServiceName: func(ctx context.Context) error {
+				_, err := store.ListCourses(ctx, 0, 1)
+				return err
+			},
+		},
+		stopping: make(chan struct{}),
+	}
+
+	// Nothing is served before the first check
+	h.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	for service := range h.probes {
+		h.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	return h
+}
+
+// check probes the storage of every service and updates their status
+func (h *healthService) check() {
+	overall := healthpb.HealthCheckResponse_SERVING
+	for service, probe := range h.probes {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		err := probe(ctx)
+		cancel()
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			overall = status
+		}
+
+		previous, _ := h.Server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if previous.GetStatus() != status {
+			if err != nil {
+				log.Printf("Service %s is %s: %v", service, status, err)
+			} else {
+				log.Printf("Service %s is %s", service, status)
+			}
+		}
+		h.SetServingStatus(service, status)
+	}
+	h.SetServingStatus("", overall)
+}
+
+// run checks the services every interval until shutdown is called
+func (h *healthService) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.check()
+		case <-h.stopping:
+			return
+		}
+	}
+}
+
+// shutdown reports all services as NOT_SERVING and ends the Watch streams,
+// which would otherwise keep a graceful stop waiting until the grace period is over
+func (h *healthService) shutdown() {
+	h.stopOnce.Do(func() {
+		h.Server.Shutdown()
+		close(h.stopping)
+	})
+}
+
+func (h *healthService) Watch(request *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-h.stopping:
+			// Give the NOT_SERVING update a moment to reach the client
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := h.Server.Watch(request, &watchStream{Health_WatchServer: stream, ctx: ctx})
+	// Tell clients why the stream ended
+	select {
+	case <-h.stopping:
+		return errShuttingDown
+	default:
+		return err
+	}
+}
+
+// watchStream replaces the context of a Watch stream
+type watchStream struct {
+	healthpb.Health_WatchServer
+	ctx context.Context
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errUnreachable = errors.New("storage is unreachable")
+
+// failingStore fails the listings of students or courses while the respective flag is set
+type failingStore struct {
+	storage.Store
+	students atomic.Bool
+	courses  atomic.Bool
+}
+
+func (s *failingStore) ListStudents(ctx context.Context, afterID int32, limit int) ([]*api.Student, error) {
+	if s.students.Load() {
+		return nil, errUnreachable
+	}
+	return s.Store.ListStudents(ctx, afterID, limit)
+}
+
+func (s *failingStore) ListCourses(ctx context.Context, afterID int32, limit int) ([]*api.Course, error) {
+	if s.courses.Load() {
+		return nil, errUnreachable
+	}
+	return s.Store.ListCourses(ctx, afterID, limit)
+}
+
+// startHealthServer serves the services of store like main and returns a connection to them
+func startHealthServer(t *testing.T, store storage.Store, options ...grpc.ServerOption) (*healthService, *grpc.ClientConn) {
+	t.Helper()
+	grpcServer := grpc.NewServer(options...)
+	health := registerServices(grpcServer, store)
+	t.Cleanup(grpcServer.Stop)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener)
+
+	conn, err := grpc.Dial("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return health, conn
+}
+
+func TestHealthReportsStorageFailures(t *testing.T) {
+	memory := storage.NewMemory()
+	t.Cleanup(func() { memory.Close() })
+	store := &failingStore{Store: memory}
+	health, conn := startHealthServer(t, store)
+	client := healthpb.NewHealthClient(conn)
+
+	const (
+		students = "StudentsService"
+		courses  = "CoursesService"
+	)
+	check := func(t *testing.T, want map[string]healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		for service, want := range want {
+			response, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatalf("Check(%q): %v", service, err)
+			}
+			if response.Status != want {
+				t.Errorf("Check(%q) = %s, want %s", service, response.Status, want)
+			}
+		}
+	}
+	serving, notServing := healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_NOT_SERVING
+
+	t.Run("before the first check", func(t *testing.T) {
+		check(t, map[string]healthpb.HealthCheckResponse_ServingStatus{"": notServing, students: notServing, courses: notServing})
+	})
+
+	t.Run("storage reachable", func(t *testing.T) {
+		health.check()
+		check(t, map[string]healthpb.HealthCheckResponse_ServingStatus{"": serving, students: serving, courses: serving})
+	})
+
+	t.Run("courses unreachable", func(t *testing.T) {
+		store.courses.Store(true)
+		health.check()
+		check(t, map[string]healthpb.HealthCheckResponse_ServingStatus{"": notServing, students: serving, courses: notServing})
+	})
+
+	t.Run("storage recovered", func(t *testing.T) {
+		store.courses.Store(false)
+		health.check()
+		check(t, map[string]healthpb.HealthCheckResponse_ServingStatus{"": serving, students: serving, courses: serving})
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "TeachersService"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Check(TeachersService) = %v, want NotFound", err)
+		}
+	})
+}
+
+func TestHealthWatchesPeriodicChecks(t *testing.T) {
+	memory := storage.NewMemory()
+	t.Cleanup(func() { memory.Close() })
+	store := &failingStore{Store: memory}
+	health, conn := startHealthServer(t, store)
+	health.check()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{Service: "StudentsService"})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if response, err := stream.Recv(); err != nil || response.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("first update = %v, %v, want SERVING", response, err)
+	}
+
+	go health.run(10 * time.Millisecond)
+	store.students.Store(true)
+	if response, err := stream.Recv(); err != nil || response.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("update after the storage failed = %v, %v, want NOT_SERVING", response, err)
+	}
+
+	// Shutting down ends the stream, which would otherwise delay a graceful stop
+	health.shutdown()
+	for {
+		_, err := stream.Recv()
+		if err == nil {
+			continue
+		}
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("Recv after shutdown = %v, want Unavailable", err)
+		}
+		break
+	}
+}
+
+func TestReflectionListsServices(t *testing.T) {
+	memory := storage.NewMemory()
+	t.Cleanup(func() { memory.Close() })
+	_, conn := startHealthServer(t, memory)
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerReflectionInfo: %v", err)
+	}
+	request := &reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}}
+	if err := stream.Send(request); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	response, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+
+	var services []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	sort.Strings(services)
+	want := []string{
+		"CoursesService",
+		"StudentsService",
+		"grpc.health.v1.Health",
+		"grpc.reflection.v1.ServerReflection",
+		"grpc.reflection.v1alpha.ServerReflection",
+	}
+	if !slices.Equal(services, want) {
+		t.Errorf("services = %v, want %v", services, want)
+	}
+}
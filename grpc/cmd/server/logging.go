@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// requestIDKey is the metadata key of the request ID.
+// Clients may send one, otherwise it is generated. It is returned in the response header.
+const requestIDKey = "x-request-id"
+
+// validRequestID limits request IDs sent by clients to something safe to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// logFormats lists the values accepted by newLogHandler
+var logFormats = []string{"text", "json"}
+
+// newLogHandler returns a handler writing records of at least the given level to stderr
+func newLogHandler(format, level string) (slog.Handler, error) {
+	var options slog.HandlerOptions
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, err
+	}
+	options.Level = l
+
+	switch format {
+	case "text":
+		return slog.NewTextHandler(os.Stderr, &options), nil
+	case "json":
+		return slog.NewJSONHandler(os.Stderr, &options), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// newAuditLogger returns a logger appending JSON lines to file, or the default logger if file is empty
+func newAuditLogger(file string) (*slog.Logger, error) {
+	if file == "" {
+		return slog.Default(), nil
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return slog.New(slog.NewJSONHandler(f, nil)), nil
+}
+
+type loggerKey struct{}
+
+// rpcLog holds the logger of an RPC. Attributes added by interceptors after the logging
+// interceptor, e.g. the authenticated subject, also appear in the record of the finished RPC.
+type rpcLog struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// logger returns the logger of an RPC, which adds the method, peer and request ID to every record.
+// Outside of RPCs it returns the default logger.
+func logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*rpcLog); ok {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.logger
+	}
+	return slog.Default()
+}
+
+// addLogAttrs adds attributes to all following records of the RPC
+func addLogAttrs(ctx context.Context, args ...any) {
+	if l, ok := ctx.Value(loggerKey{}).(*rpcLog); ok {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.logger = l.logger.With(args...)
+	}
+}
+
+// logUnary logs every unary RPC once it is finished
+func logUnary(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = rpcLogger(ctx, info.FullMethod)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID(ctx)))
+
+	response, err := handler(ctx, request)
+
+	var sent int64
+	if err == nil {
+		sent = 1
+	}
+	logFinished(logger(ctx), start, err, 1, sent)
+	return response, err
+}
+
+// logStream logs every streaming RPC once it is finished
+func logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := rpcLogger(stream.Context(), info.FullMethod)
+	stream.SetHeader(metadata.Pairs(requestIDKey, requestID(ctx)))
+
+	counting := &countingStream{ServerStream: stream, ctx: ctx}
+	err := handler(srv, counting)
+
+	logFinished(logger(ctx), start, err, counting.received.Load(), counting.sent.Load())
+	return err
+}
+
+type requestIDContextKey struct{}
+
+// rpcLogger returns a context carrying the request ID and the logger of an RPC
+func rpcLogger(ctx context.Context, method string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var id string
+	if values := md.Get(requestIDKey); len(values) > 0 && validRequestID.MatchString(values[0]) {
+		id = values[0]
+	} else {
+		id = newRequestID()
+	}
+
+	l := slog.Default().With(
+		slog.String("method", method),
+		slog.String("peer", peerAddress(ctx, md)),
+		slog.String("request_id", id),
+	)
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		l = l.With(slog.String("trace_id", span.TraceID().String()))
+	}
+
+	ctx = context.WithValue(ctx, requestIDContextKey{}, id)
+	return context.WithValue(ctx, loggerKey{}, &rpcLog{logger: l})
+}
+
+// requestID returns the request ID of an RPC
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// peerAddress returns the address of the client. Requests of the gateway and the
+// gRPC-Web/Connect handler arrive over the internal listener, their client is the
+// last entry of X-Forwarded-For, which is added by the handlers.
+func peerAddress(ctx context.Context, md metadata.MD) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if p.Addr.Network() == "bufconn" {
+		if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+
+	return p.Addr.String()
+}
+
+func logFinished(l *slog.Logger, start time.Time, err error, received, sent int64) {
+	code := status.Code(err)
+	attrs := []slog.Attr{
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int64("received", received),
+		slog.Int64("sent", sent),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	l.LogAttrs(context.Background(), levelFor(code), "RPC finished", attrs...)
+}
+
+// levelFor logs client errors at info and server errors at warn or error level
+func levelFor(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.Unauthenticated:
+		return slog.LevelInfo
+	case codes.Unknown, codes.Unimplemented, codes.Internal, codes.DataLoss:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// countingStream counts the messages of a stream and carries the logger in its context
+type countingStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+func (s *countingStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *countingStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+func (s *countingStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
+
+// forwardedContext passes the client address, request ID, trace context and credentials
+// of an HTTP request to the RPCs called for it
+func forwardedContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		md.Set("x-forwarded-for", host)
+	}
+	for _, key := range []string{requestIDKey, "traceparent", "tracestate", "baggage", "authorization"} {
+		if value := r.Header.Get(key); value != "" {
+			md.Set(key, value)
+		}
+	}
+	return metadata.NewOutgoingContext(r.Context(), md)
+}
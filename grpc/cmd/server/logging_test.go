@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// captureLogs makes the default logger write JSON records of all levels to the returned buffer
+func captureLogs(t *testing.T) *testAudit {
+	t.Helper()
+	var buffer bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &testAudit{buffer: &buffer}
+}
+
+// finished returns the "RPC finished" record of method
+func finished(t *testing.T, records []map[string]any, method string) map[string]any {
+	t.Helper()
+	for _, record := range records {
+		if record["msg"] == "RPC finished" && record["method"] == method {
+			return record
+		}
+	}
+	t.Fatalf("no record of %s in %v", method, records)
+	return nil
+}
+
+func TestLogRecords(t *testing.T) {
+	logs := captureLogs(t)
+	// Logs through the logger of the RPC, like handlers
+	handlerLog := func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		logger(ctx).Info("in handler")
+		addLogAttrs(ctx, slog.String("subject", "ada"))
+		return handler(ctx, request)
+	}
+	s := startTestServer(t,
+		grpc.ChainUnaryInterceptor(logUnary, handlerLog, validateUnary),
+		grpc.ChainStreamInterceptor(logStream, validateStream),
+	)
+	client := api.NewStudentsServiceClient(s.dial(t))
+
+	t.Run("unary", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "request-1")
+		var header metadata.MD
+		if _, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 1}, grpc.Header(&header)); err != nil {
+			t.Fatalf("GetStudentById: %v", err)
+		}
+		if got := header.Get(requestIDKey); len(got) != 1 || got[0] != "request-1" {
+			t.Errorf("%s header = %v, want request-1", requestIDKey, got)
+		}
+
+		records := logs.records(t)
+		if len(records) != 2 {
+			t.Fatalf("records = %v, want one of the handler and one of the finished RPC", records)
+		}
+		if in := records[0]; in["msg"] != "in handler" || in["method"] != "/StudentsService/GetStudentById" || in["request_id"] != "request-1" {
+			t.Errorf("record of the handler = %v, want the method and request ID", in)
+		}
+
+		record := finished(t, records, "/StudentsService/GetStudentById")
+		for key, want := range map[string]any{
+			"level":      "INFO",
+			"code":       "OK",
+			"request_id": "request-1",
+			"received":   float64(1),
+			"sent":       float64(1),
+			"subject":    "ada",
+		} {
+			if record[key] != want {
+				t.Errorf("%s = %v, want %v", key, record[key], want)
+			}
+		}
+		if duration, ok := record["duration"].(float64); !ok || duration <= 0 {
+			t.Errorf("duration = %v, want a positive number of nanoseconds", record["duration"])
+		}
+		if peer, _ := record["peer"].(string); !strings.HasPrefix(peer, "127.0.0.1") {
+			t.Errorf("peer = %v, want the address of the client", record["peer"])
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "not a valid ID")
+		if _, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 2}); err == nil {
+			t.Fatal("GetStudentById of an unknown student succeeded")
+		}
+
+		record := finished(t, logs.records(t), "/StudentsService/GetStudentById")
+		if record["code"] != codes.NotFound.String() || record["sent"] != float64(0) || record["error"] == nil {
+			t.Errorf("record = %v, want NotFound with an error and no sent message", record)
+		}
+		// Request IDs of clients are logged only if they are safe
+		if id, _ := record["request_id"].(string); !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(id) {
+			t.Errorf("request_id = %q, want a generated one", id)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		stream, err := client.ImportStudentsV2(context.Background())
+		if err != nil {
+			t.Fatalf("ImportStudentsV2: %v", err)
+		}
+		for _, name := range []string{"Grace Hopper", "Alan Turing"} {
+			if err := stream.Send(&api.ImportStudentsV2Request{Students: []*api.Student{{Name: name}}}); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+		}
+		stream.CloseSend()
+		for {
+			if _, err := stream.Recv(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Recv: %v", err)
+			}
+		}
+
+		record := finished(t, logs.records(t), "/StudentsService/ImportStudentsV2")
+		if record["code"] != "OK" || record["received"] != float64(2) || record["sent"] != float64(2) {
+			t.Errorf("record = %v, want OK with 2 received and 2 sent messages", record)
+		}
+	})
+
+	t.Run("no student names", func(t *testing.T) {
+		if _, err := client.CreateStudent(context.Background(), &api.CreateStudentRequest{Student: &api.Student{Name: "Edsger Dijkstra"}}); err != nil {
+			t.Fatalf("CreateStudent: %v", err)
+		}
+		stream, err := client.ImportStudents(context.Background())
+		if err != nil {
+			t.Fatalf("ImportStudents: %v", err)
+		}
+		if err := stream.Send(&api.ImportStudentsRequest{Students: []*api.Student{{Name: "Barbara Liskov"}}}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		if _, err := stream.CloseAndRecv(); err != nil {
+			t.Fatalf("CloseAndRecv: %v", err)
+		}
+
+		output := logs.buffer.String()
+		if !strings.Contains(output, "/StudentsService/ImportStudents") {
+			t.Fatalf("no record of ImportStudents in %s", output)
+		}
+		for _, name := range []string{"Edsger Dijkstra", "Barbara Liskov"} {
+			if strings.Contains(output, name) {
+				t.Errorf("the logs contain the name %s: %s", name, output)
+			}
+		}
+	})
+}
+
+func TestLoggerOutsideOfRPCs(t *testing.T) {
+	if logger(context.Background()) != slog.Default() {
+		t.Error("logger outside of an RPC is not the default logger")
+	}
+	// Does nothing without the logger of an RPC
+	addLogAttrs(context.Background(), slog.String("subject", "ada"))
+}
@@ -2,47 +2,65 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"github.com/go-faker/faker/v4"
 	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/config"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/rest"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"io"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
 	"log"
-	"math/rand"
-	"net"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
-type server struct {
-	api.StudentsServiceServer
-}
+// seed fills an empty repository with n fake students
+func seed(store storage.Store, n int) error {
+	existing, err := store.ListStudents(context.Background(), 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
 
-func (s *server) GetStudentById(_ context.Context, request *api.GetStudentByIdRequest) (*api.Student, error) {
-	response := &api.Student{
-		Id:   request.Id,
-		Name: "John Doe",
+	students := make([]*api.Student, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("%s %s", faker.FirstName(), faker.LastName())
+		students[i] = &api.Student{Name: name}
 	}
 
-	return response, nil
+	return store.ImportStudents(context.Background(), students)
 }
 
-func (s *server) GetStudents(request *api.GetStudentsRequest, stream api.StudentsService_GetStudentsServer) error {
-	id := int32(1)
-	for i := 1; i <= 10; i++ {
-		// Simulate database query/network request
-		time.Sleep(time.Second)
-
-		// Generate fake data
-		students := make([]*api.Student, request.PerMessage)
-		for j := 0; j < int(request.PerMessage); j++ {
-			name := fmt.Sprintf("%s %s", faker.FirstName(), faker.LastName())
-			students[j] = &api.Student{Id: id, Name: name}
-			id++
-		}
-
-		response := api.GetStudentsResponse{Students: students}
+// seedCourses adds a few courses to an empty repository
+func seedCourses(store storage.Store) error {
+	existing, err := store.ListCourses(context.Background(), 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
 
-		if err := stream.Send(&response); err != nil {
+	courses := []*api.Course{
+		{Name: "Cloud Computing", Description: "Distributed systems, containers and APIs", Capacity: 30},
+		{Name: "Databases", Description: "Relational modelling and SQL"},
+		{Name: "Software Engineering", Description: "Requirements, design and testing"},
+	}
+	for _, course := range courses {
+		if err := store.CreateCourse(context.Background(), course); err != nil {
 			return err
 		}
 	}
@@ -50,81 +68,185 @@ func (s *server) GetStudents(request *api.GetStudentsRequest, stream api.Student
 	return nil
 }
 
-func (s *server) ImportStudents(stream api.StudentsService_ImportStudentsServer) error {
-	count := int32(0)
-
-	for {
-		message, err := stream.Recv()
-
-		if err == io.EOF {
-			// No more messages on the stream
-			log.Print("Received EOF")
-			summary := api.ImportStudentsResponse{Count: count}
-			return stream.SendAndClose(&summary)
-		}
+func main() {
+	var transport config.Server
+	transport.RegisterFlags(flag.CommandLine)
+	var tracing config.Tracing
+	tracing.RegisterFlags(flag.CommandLine)
+	var authentication config.Auth
+	authentication.RegisterFlags(flag.CommandLine)
+	backend := flag.String("storage", "memory", "storage backend: "+strings.Join(storage.Backends, ", "))
+	path := flag.String("db", "students.db", "path to the database file (sqlite and bolt)")
+	restAddress := flag.String("rest", "127.0.0.1:3001", "listen address of the REST API, empty to disable")
+	gatewayAddress := flag.String("gateway", "127.0.0.1:3002", "listen address of the JSON/HTTP gateway, empty to disable")
+	metricsAddress := flag.String("metrics", "127.0.0.1:3004", "listen address of the Prometheus metrics endpoint, empty to disable")
+	corsOrigins := flag.String("cors", "", "comma-separated origins allowed to call the services from a browser, * for any")
+	grace := flag.Duration("shutdown-grace", 30*time.Second, "time for active requests to finish on SIGINT or SIGTERM")
+	logFormat := flag.String("log-format", "text", "format of the log records: "+strings.Join(logFormats, ", "))
+	auditLog := flag.String("audit-log", "", "file the authentication and authorization decisions are appended to as JSON lines, default the server log")
+	logLevel := flag.String("log-level", "info", "minimum level of the log records: debug, info, warn or error")
+	healthInterval := flag.Duration("health-interval", 5*time.Second, "interval of the storage checks reported by the health service")
+	if err := config.Load(flag.CommandLine, config.ServerEnvPrefix, os.Args[1:]); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
-		if err != nil {
-			return err
-		}
+	logHandler, err := newLogHandler(*logFormat, *logLevel)
+	if err != nil {
+		log.Fatalf("Invalid log configuration: %v", err)
+	}
+	// Also used by the log package
+	slog.SetDefault(slog.New(logHandler))
 
-		// Process message
-		for _, student := range message.Students {
-			log.Printf("Importing student: %s", student.Name)
-			time.Sleep(200 * time.Millisecond)
-			count++
-		}
+	stopTracing, err := tracing.Start(context.Background(), "students-server")
+	if err != nil {
+		log.Fatalf("Invalid tracing configuration: %v", err)
 	}
-}
 
-func (s *server) ImportStudentsV2(stream api.StudentsService_ImportStudentsV2Server) error {
-	generateRandomNumber := func(min, max int32) int32 {
-		return rand.Int31n(max-min+1) + min
+	tlsConfig, err := transport.TLS.Config()
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
 	}
 
-	for {
-		in, err := stream.Recv()
-		if err == io.EOF {
-			// No more incoming messages
-			return nil
-		}
-		if err != nil {
-			return err
-		}
+	listener, err := transport.Listen()
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
 
-		log.Printf("Importing %d students", len(in.Students))
+	store, err := storage.Open(*backend, *path)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+	store = storage.Observe(storage.Observe(store, observeStorage), traceStorage(*backend))
 
-		// Do some work
-		time.Sleep(500 * time.Millisecond)
+	if err := seed(store, 50); err != nil {
+		log.Fatalf("Failed to seed repository: %v", err)
+	}
+	if err := seedCourses(store); err != nil {
+		log.Fatalf("Failed to seed repository: %v", err)
+	}
 
-		log.Print("Generating IDs...")
-		for _, student := range in.Students {
-			student.Id = generateRandomNumber(1, 10000)
-		}
+	verifier, err := authentication.Verifier(context.Background())
+	if err != nil {
+		log.Fatalf("Invalid authentication configuration: %v", err)
+	}
+	policy, err := authentication.Policy()
+	if err != nil {
+		log.Fatalf("Invalid authorization configuration: %v", err)
+	}
 
-		log.Print("Sending response...")
-		message := api.ImportStudentsV2Response{Students: in.Students}
-		err = stream.Send(&message)
+	drain := newDrain()
+	unary := []grpc.UnaryServerInterceptor{logUnary, metricsUnary, drain.unary}
+	stream := []grpc.StreamServerInterceptor{logStream, metricsStream, drain.stream}
+	var authn *authenticator
+	var authz *authorizer
+	if verifier != nil {
+		audit, err := newAuditLogger(*auditLog)
 		if err != nil {
-			log.Fatalf("Error: %v", err)
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		authn = &authenticator{verifier: verifier, audit: audit}
+		unary = append(unary, authn.unary)
+		stream = append(stream, authn.stream)
+		if policy != nil {
+			authz = &authorizer{policy: policy, audit: audit}
+			unary = append(unary, authz.unary)
+			stream = append(stream, authz.stream)
 		}
+	} else {
+		log.Print("Authentication is disabled, set -auth-jwks to enable it")
+	}
+	options := append(transport.Options(),
+		// Creates a span per RPC with an event per message, continuing the trace of the caller
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithMessageEvents(otelgrpc.ReceivedEvents, otelgrpc.SentEvents))),
+		grpc.ChainUnaryInterceptor(append(unary, validateUnary)...),
+		grpc.ChainStreamInterceptor(append(stream, validateStream)...),
+	)
+	grpcServer := grpc.NewServer(options...)
+	health := registerServices(grpcServer, store)
+	health.check()
+	go health.run(*healthInterval)
+
+	// The gateway and the handler of the shared listener call the gRPC server in-process,
+	// so they need no client certificate
+	internal := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(internal)
+
+	var origins []string
+	if *corsOrigins != "" {
+		origins = strings.Split(*corsOrigins, ",")
 	}
-}
 
-func main() {
-	listener, err := net.Listen("tcp", "127.0.0.1:3000")
+	var servers []*http.Server
+	httpTLSConfig := withoutClientAuth(tlsConfig)
+	if *restAddress != "" {
+		servers = append(servers, serveREST(*restAddress, store, authn, authz, httpTLSConfig))
+	}
+	if *gatewayAddress != "" {
+		servers = append(servers, serveGateway(*gatewayAddress, internal.DialContext, origins, httpTLSConfig))
+	}
+	if *metricsAddress != "" {
+		servers = append(servers, serveMetrics(*metricsAddress, httpTLSConfig))
+	}
+
+	handler, err := newWebHandler(grpcServer, internal.DialContext, origins)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		log.Fatalf("Failed to create gRPC-Web/Connect handler: %v", err)
+	}
+	webServer, err := newWebServer(handler, transport, tlsConfig)
+	if err != nil {
+		log.Fatalf("Failed to create gRPC server: %v", err)
 	}
+	servers = append(servers, webServer)
 
-	grpcServer := grpc.NewServer()
-	server := server{}
+	log.Print("Starting server...")
+	if tlsConfig != nil {
+		log.Printf("Listening on %s with TLS (gRPC, gRPC-Web, Connect)", listener.Addr())
+	} else {
+		log.Printf("Listening on %s (gRPC, gRPC-Web, Connect)", listener.Addr())
+	}
 
-	api.RegisterStudentsServiceServer(grpcServer, &server)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	log.Print("Starting server...")
-	log.Printf("Listening on %s", listener.Addr())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- serve(webServer, listener)
+	}()
 
-	if err := grpcServer.Serve(listener); err != nil {
+	select {
+	case err := <-errs:
 		log.Fatalf("Error starting server: %v", err)
+	case <-ctx.Done():
 	}
+
+	// A second signal terminates the server immediately
+	stop()
+	log.Printf("Shutting down, waiting up to %s for active requests", *grace)
+	health.shutdown()
+	drain.shutdown(*grace, grpcServer, servers...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), forceTimeout)
+	defer cancel()
+	if err := stopTracing(ctx); err != nil {
+		log.Printf("Could not export traces: %v", err)
+	}
+	log.Print("Server stopped")
+}
+
+// registerServices registers the services of store with grpcServer, along with health checks
+// and reflection, e.g. for grpcurl. The returned health service has not checked the storage yet.
+func registerServices(grpcServer *grpc.Server, store storage.Store) *healthService {
+	api.RegisterStudentsServiceServer(grpcServer, &server{store: store})
+	api.RegisterCoursesServiceServer(grpcServer, &coursesServer{store: store})
+
+	health := newHealthService(store)
+	healthpb.RegisterHealthServer(grpcServer, health)
+	reflection.Register(grpcServer)
+	return health
+}
+
+// serveREST serves the REST API described in swagger/students.yaml in the background
+func serveREST(address string, store storage.Store, authn *authenticator, authz *authorizer, tlsConfig *tls.Config) *http.Server {
+	return startHTTP("REST API", address, protectREST(rest.NewHandler(store), authn, authz), tlsConfig)
 }
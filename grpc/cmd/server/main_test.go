@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/config"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// testServer runs the services like main: native gRPC, gRPC-Web and Connect share a TCP
+// listener, the gateway has its own. All reach the gRPC server over an internal listener.
+type testServer struct {
+	grpc    *grpc.Server
+	web     *http.Server
+	gateway *httptest.Server
+	// Address of the shared listener
+	address string
+	// Number of connections accepted by web
+	webConnections atomic.Int64
+}
+
+// startTestServer serves a store with a student and a course. options are added to those of main.
+func startTestServer(t *testing.T, options ...grpc.ServerOption) *testServer {
+	t.Helper()
+	return startTLSTestServer(t, nil, options...)
+}
+
+// startTLSTestServer is startTestServer with TLS on the shared listener if tlsConfig is not nil
+func startTLSTestServer(t *testing.T, tlsConfig *tls.Config, options ...grpc.ServerOption) *testServer {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	store := storage.NewMemory()
+	if err := store.ImportStudents(ctx, []*api.Student{{Name: "Ada Lovelace"}}); err != nil {
+		t.Fatalf("ImportStudents: %v", err)
+	}
+	if err := store.CreateCourse(ctx, &api.Course{Name: "Cloud Computing"}); err != nil {
+		t.Fatalf("CreateCourse: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(options...)
+	api.RegisterStudentsServiceServer(grpcServer, &server{store: store})
+	api.RegisterCoursesServiceServer(grpcServer, &coursesServer{store: store})
+	t.Cleanup(grpcServer.Stop)
+
+	internal := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(internal)
+
+	handler, err := newWebHandler(grpcServer, internal.DialContext, []string{"*"})
+	if err != nil {
+		t.Fatalf("newWebHandler: %v", err)
+	}
+	web, err := newWebServer(handler, config.Server{}, tlsConfig)
+	if err != nil {
+		t.Fatalf("newWebServer: %v", err)
+	}
+	s := &testServer{grpc: grpcServer, web: web}
+	web.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.webConnections.Add(1)
+		}
+	}
+	t.Cleanup(func() { web.Close() })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	s.address = listener.Addr().String()
+	go serve(web, listener)
+
+	gatewayHandler, err := newGateway(ctx, internal.DialContext, []string{"*"})
+	if err != nil {
+		t.Fatalf("newGateway: %v", err)
+	}
+	s.gateway = httptest.NewServer(gatewayHandler)
+	t.Cleanup(s.gateway.Close)
+
+	return s
+}
+
+// dial returns a native gRPC connection to the shared listener, without TLS unless options say otherwise
+func (s *testServer) dial(t *testing.T, options ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.Dial(s.address, append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, options...)...)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Metrics of the gRPC server, named like those of go-grpc-prometheus so existing dashboards work
+var (
+	rpcsStarted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_started_total",
+		Help: "Total number of RPCs started on the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+	rpcsHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of RPCs completed on the server, regardless of success or failure.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Histogram of response latency (seconds) of RPCs handled by the server.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+	messagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_msg_received_total",
+		Help: "Total number of messages received by the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+	messagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_msg_sent_total",
+		Help: "Total number of messages sent by the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+	streamMessages = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_stream_messages",
+		Help:    "Histogram of the number of messages per stream.",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 6),
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "direction"})
+
+	studentsImported = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "students_imported_total",
+		Help: "Total number of students stored by ImportStudents, ImportStudentsV2 and CreateStudent.",
+	}, []string{"grpc_method"})
+
+	storageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "storage_operation_duration_seconds",
+		Help:    "Histogram of the duration of storage operations.",
+		Buckets: []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "result"})
+)
+
+// metricsUnary records the metrics of unary RPCs
+func metricsUnary(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	labels := rpcLabels("unary", info.FullMethod)
+	rpcsStarted.With(labels).Inc()
+	messagesReceived.With(labels).Inc()
+	start := time.Now()
+
+	response, err := handler(ctx, request)
+
+	rpcDuration.With(labels).Observe(time.Since(start).Seconds())
+	rpcsHandled.MustCurryWith(labels).WithLabelValues(status.Code(err).String()).Inc()
+	if err == nil {
+		messagesSent.With(labels).Inc()
+	}
+	return response, err
+}
+
+// metricsStream records the metrics of streaming RPCs, including the number of messages per stream
+func metricsStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	labels := rpcLabels(streamType(info), info.FullMethod)
+	rpcsStarted.With(labels).Inc()
+	start := time.Now()
+
+	counting := &countingStream{ServerStream: stream, ctx: stream.Context()}
+	err := handler(srv, counting)
+
+	rpcDuration.With(labels).Observe(time.Since(start).Seconds())
+	rpcsHandled.MustCurryWith(labels).WithLabelValues(status.Code(err).String()).Inc()
+
+	received, sent := counting.received.Load(), counting.sent.Load()
+	messagesReceived.With(labels).Add(float64(received))
+	messagesSent.With(labels).Add(float64(sent))
+	perStream := streamMessages.MustCurryWith(labels)
+	perStream.WithLabelValues("received").Observe(float64(received))
+	perStream.WithLabelValues("sent").Observe(float64(sent))
+
+	return err
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
+
+// rpcLabels splits a method name like /StudentsService/GetStudents into its service and method
+func rpcLabels(rpcType, fullMethod string) prometheus.Labels {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return prometheus.Labels{"grpc_type": rpcType, "grpc_service": service, "grpc_method": method}
+}
+
+// observeStorage records the duration of storage operations
+func observeStorage(ctx context.Context, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		storageDuration.WithLabelValues(operation, storageResult(err)).Observe(time.Since(start).Seconds())
+	}
+}
+
+// storageResult classifies the result of a storage operation as ok, rejected or error
+func storageResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrAlreadyExists), errors.Is(err, storage.ErrCourseFull):
+		// Expected outcomes, not failures of the storage
+		return "rejected"
+	default:
+		return "error"
+	}
+}
+
+// serveMetrics serves the metrics in the Prometheus text format on /metrics in the background
+func serveMetrics(address string, tlsConfig *tls.Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return startHTTP("metrics", address, mux, tlsConfig)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrape returns the samples served on /metrics by their name and labels, like
+// grpc_server_started_total{grpc_method="CreateStudent",grpc_service="StudentsService",grpc_type="unary"}
+func scrape(t *testing.T) map[string]float64 {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, want 200", recorder.Code)
+	}
+
+	samples := make(map[string]float64)
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		separator := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[separator+1:], 64)
+		if err != nil {
+			t.Fatalf("sample %q: %v", line, err)
+		}
+		samples[line[:separator]] = value
+	}
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	memory := storage.NewMemory()
+	t.Cleanup(func() { memory.Close() })
+	_, conn := startHealthServer(t, storage.Observe(memory, observeStorage),
+		grpc.UnaryInterceptor(metricsUnary),
+		grpc.StreamInterceptor(metricsStream),
+	)
+	client := api.NewStudentsServiceClient(conn)
+	// The metrics are global, so only their increase is checked
+	before := scrape(t)
+
+	if _, err := client.CreateStudent(context.Background(), &api.CreateStudentRequest{Student: &api.Student{Name: "Ada Lovelace"}}); err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	if _, err := client.GetStudentById(context.Background(), &api.GetStudentByIdRequest{Id: 1}); err != nil {
+		t.Fatalf("GetStudentById: %v", err)
+	}
+	if _, err := client.GetStudentById(context.Background(), &api.GetStudentByIdRequest{Id: 42}); status.Code(err) != codes.NotFound {
+		t.Fatalf("GetStudentById of an unknown student = %v, want NotFound", err)
+	}
+	stream, err := client.ImportStudents(context.Background())
+	if err != nil {
+		t.Fatalf("ImportStudents: %v", err)
+	}
+	for _, students := range [][]*api.Student{{{Name: "Grace Hopper"}, {Name: "Alan Turing"}}, {{Name: "Barbara Liskov"}}} {
+		if err := stream.Send(&api.ImportStudentsRequest{Students: students}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+
+	after := scrape(t)
+	const (
+		createStudent  = `grpc_method="CreateStudent",grpc_service="StudentsService",grpc_type="unary"`
+		getStudent     = `grpc_method="GetStudentById",grpc_service="StudentsService",grpc_type="unary"`
+		importStudents = `grpc_method="ImportStudents",grpc_service="StudentsService",grpc_type="client_stream"`
+	)
+	for sample, want := range map[string]float64{
+		"grpc_server_started_total{" + createStudent + "}":                                 1,
+		"grpc_server_handled_total{grpc_code=\"OK\"," + createStudent + "}":                1,
+		"grpc_server_handling_seconds_count{" + createStudent + "}":                        1,
+		"grpc_server_started_total{" + getStudent + "}":                                    2,
+		"grpc_server_handled_total{grpc_code=\"OK\"," + getStudent + "}":                   1,
+		"grpc_server_handled_total{grpc_code=\"NotFound\"," + getStudent + "}":             1,
+		"grpc_server_handling_seconds_count{" + getStudent + "}":                           2,
+		"grpc_server_msg_received_total{" + getStudent + "}":                               2,
+		"grpc_server_msg_sent_total{" + getStudent + "}":                                   1,
+		"grpc_server_handled_total{grpc_code=\"OK\"," + importStudents + "}":               1,
+		"grpc_server_msg_received_total{" + importStudents + "}":                           2,
+		"grpc_server_msg_sent_total{" + importStudents + "}":                               1,
+		"grpc_server_stream_messages_count{direction=\"received\"," + importStudents + "}": 1,
+		"grpc_server_stream_messages_sum{direction=\"received\"," + importStudents + "}":   2,
+		"grpc_server_stream_messages_sum{direction=\"sent\"," + importStudents + "}":       1,
+
+		`students_imported_total{grpc_method="CreateStudent"}`:  1,
+		`students_imported_total{grpc_method="ImportStudents"}`: 3,
+
+		`storage_operation_duration_seconds_count{operation="ImportStudents",result="ok"}`:   2,
+		`storage_operation_duration_seconds_count{operation="GetStudent",result="ok"}`:       1,
+		`storage_operation_duration_seconds_count{operation="GetStudent",result="rejected"}`: 1,
+	} {
+		if got := after[sample] - before[sample]; got != want {
+			t.Errorf("%s increased by %v, want %v", sample, got, want)
+		}
+	}
+
+	// ImportStudents processes each student for 200ms
+	if got := after["grpc_server_handling_seconds_sum{"+importStudents+"}"] - before["grpc_server_handling_seconds_sum{"+importStudents+"}"]; got < 0.6 {
+		t.Errorf("duration of ImportStudents increased by %vs, want at least 0.6s", got)
+	}
+}
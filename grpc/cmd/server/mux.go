@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/tls"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/config"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// readHeaderTimeout bounds the TLS handshake and the request headers of new connections,
+// so idle peers cannot hold connections open before sending a request
+const readHeaderTimeout = 10 * time.Second
+
+// newWebServer returns a server for native gRPC, gRPC-Web and the Connect protocol on one listener,
+// see newWebHandler. It speaks HTTP/2 with TLS if tlsConfig is not nil, HTTP/2 without TLS (h2c)
+// otherwise, and HTTP/1.1 for gRPC-Web and Connect clients in both cases. Of the keepalive
+// settings in transport, only MaxConnectionIdle applies to the connections of clients.
+func newWebServer(handler http.Handler, transport config.Server, tlsConfig *tls.Config) (*http.Server, error) {
+	h2 := &http2.Server{
+		MaxConcurrentStreams: uint32(transport.MaxConcurrentStreams),
+		IdleTimeout:          transport.Keepalive.MaxConnectionIdle,
+	}
+	server := &http.Server{
+		Handler:           h2c.NewHandler(handler, h2),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	if tlsConfig != nil {
+		// ConfigureServer adds h2 to the protocols of the config
+		server.TLSConfig = tlsConfig.Clone()
+		if err := http2.ConfigureServer(server, h2); err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+// serve serves the server of newWebServer on listener until it is shut down
+func serve(server *http.Server, listener net.Listener) error {
+	if server.TLSConfig != nil {
+		// The certificates are provided by the config
+		return server.ServeTLS(listener, "", "")
+	}
+	return server.Serve(listener)
+}
+
+// isGRPC reports whether a request uses the native gRPC protocol, but not gRPC-Web
+func isGRPC(contentType string) bool {
+	return contentType == "application/grpc" ||
+		strings.HasPrefix(contentType, "application/grpc+") ||
+		strings.HasPrefix(contentType, "application/grpc;")
+}
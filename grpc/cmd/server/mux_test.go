@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// h2cClient speaks HTTP/2 without TLS, like Connect clients outside of browsers
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, address string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}}
+}
+
+func post(t *testing.T, client *http.Client, url, contentType string, body []byte, header http.Header) (*http.Response, []byte) {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("POST %s: reading body: %v", url, err)
+	}
+	return response, data
+}
+
+// envelope prefixes data with flags and its length, like the messages of gRPC-Web and Connect streams
+func envelope(flags byte, data []byte) []byte {
+	frame := make([]byte, 5, 5+len(data))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+// envelopedMessage is a message of a gRPC-Web or Connect stream
+type envelopedMessage struct {
+	flags byte
+	data  []byte
+}
+
+// parseEnvelopes splits a gRPC-Web or Connect response body into its messages
+func parseEnvelopes(t *testing.T, body []byte) []envelopedMessage {
+	t.Helper()
+	var messages []envelopedMessage
+	for len(body) > 0 {
+		if len(body) < 5 {
+			t.Fatalf("body ends with %q, want a message", body)
+		}
+		length := int(binary.BigEndian.Uint32(body[1:5]))
+		if len(body) < 5+length {
+			t.Fatalf("message of %d bytes has only %d", length, len(body)-5)
+		}
+		messages = append(messages, envelopedMessage{flags: body[0], data: body[5 : 5+length]})
+		body = body[5+length:]
+	}
+	return messages
+}
+
+// grpcWebFrame frames a message like gRPC-Web clients
+func grpcWebFrame(t *testing.T, message proto.Message) []byte {
+	t.Helper()
+	data, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return envelope(0, data)
+}
+
+// checkGRPCWebStudent checks that a gRPC-Web response body has the student with ID 1 and status OK
+func checkGRPCWebStudent(t *testing.T, body []byte) {
+	t.Helper()
+	messages := parseEnvelopes(t, body)
+	if len(messages) != 2 || messages[0].flags != 0 {
+		t.Fatalf("body = %q, want a message and trailers", body)
+	}
+	var student api.Student
+	if err := proto.Unmarshal(messages[0].data, &student); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if student.Name != "Ada Lovelace" {
+		t.Errorf("student = %v, want Ada Lovelace", &student)
+	}
+	checkGRPCWebTrailers(t, messages[1])
+}
+
+// checkGRPCWebTrailers checks that the last message of a gRPC-Web response has the trailers of status OK
+func checkGRPCWebTrailers(t *testing.T, message envelopedMessage) {
+	t.Helper()
+	const trailersFlag = 0x80
+	if trailers := string(message.data); message.flags&trailersFlag == 0 || !strings.Contains(strings.ToLower(trailers), "grpc-status: 0") {
+		t.Errorf("trailers = %q with flags %#x, want grpc-status 0", trailers, message.flags)
+	}
+}
+
+func TestServeProtocolsOnOneListener(t *testing.T) {
+	s := startTestServer(t)
+	url := "http://" + s.address + "/StudentsService/GetStudentById"
+	connectHeader := http.Header{"Connect-Protocol-Version": {"1"}}
+
+	t.Run("gRPC", func(t *testing.T) {
+		client := api.NewStudentsServiceClient(s.dial(t))
+		// Several RPCs on one connection
+		for i := 0; i < 3; i++ {
+			student, err := client.GetStudentById(context.Background(), &api.GetStudentByIdRequest{Id: 1})
+			if err != nil {
+				t.Fatalf("GetStudentById: %v", err)
+			}
+			if student.Name != "Ada Lovelace" {
+				t.Errorf("GetStudentById = %v, want Ada Lovelace", student)
+			}
+		}
+	})
+
+	t.Run("gRPC-Web over HTTP/1.1", func(t *testing.T) {
+		response, body := post(t, http.DefaultClient, url, "application/grpc-web+proto", grpcWebFrame(t, &api.GetStudentByIdRequest{Id: 1}), nil)
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d: %s", response.StatusCode, body)
+		}
+		checkGRPCWebStudent(t, body)
+	})
+
+	t.Run("gRPC-Web over HTTP/2", func(t *testing.T) {
+		// Dispatched by content type on the same HTTP/2 connections as native gRPC
+		client := h2cClient()
+		before := s.webConnections.Load()
+		for i := 0; i < 3; i++ {
+			response, body := post(t, client, url, "application/grpc-web+proto", grpcWebFrame(t, &api.GetStudentByIdRequest{Id: 1}), nil)
+			if response.StatusCode != http.StatusOK || response.ProtoMajor != 2 {
+				t.Fatalf("status = %d over HTTP/%d: %s", response.StatusCode, response.ProtoMajor, body)
+			}
+			checkGRPCWebStudent(t, body)
+		}
+		// The client retries on a new connection if the server closes one
+		if connections := s.webConnections.Load() - before; connections != 1 {
+			t.Errorf("3 requests used %d connections, want 1", connections)
+		}
+	})
+
+	for name, client := range map[string]*http.Client{"HTTP/1.1": http.DefaultClient, "HTTP/2": h2cClient()} {
+		t.Run("Connect over "+name, func(t *testing.T) {
+			before := s.webConnections.Load()
+			for i := 0; i < 3; i++ {
+				response, body := post(t, client, url, "application/json", []byte(`{"id": 1}`), connectHeader)
+				if response.StatusCode != http.StatusOK {
+					t.Fatalf("status = %d: %s", response.StatusCode, body)
+				}
+				if !strings.Contains(string(body), `"name":"Ada Lovelace"`) {
+					t.Errorf("body = %s, want Ada Lovelace", body)
+				}
+			}
+
+			if connections := s.webConnections.Load() - before; name == "HTTP/2" && connections != 1 {
+				t.Errorf("3 requests used %d connections, want 1", connections)
+			}
+
+			response, body := post(t, client, url, "application/json", []byte(`{"id": 2}`), connectHeader)
+			if response.StatusCode != http.StatusNotFound || !strings.Contains(string(body), `"code":"not_found"`) {
+				t.Errorf("student 2: status = %d, body = %s, want a not_found error", response.StatusCode, body)
+			}
+		})
+	}
+
+	t.Run("gateway", func(t *testing.T) {
+		response, err := http.Get(s.gateway.URL + "/v1/students/1")
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		if response.StatusCode != http.StatusOK || !strings.Contains(string(body), `"name":"Ada Lovelace"`) {
+			t.Errorf("GET /v1/students/1 = %d %s, want Ada Lovelace", response.StatusCode, body)
+		}
+	})
+}
+
+func TestStreamOverWebProtocols(t *testing.T) {
+	s := startTestServer(t)
+	url := "http://" + s.address + "/StudentsService/GetStudents"
+
+	for name, client := range map[string]*http.Client{"HTTP/1.1": http.DefaultClient, "HTTP/2": h2cClient()} {
+		t.Run("gRPC-Web over "+name, func(t *testing.T) {
+			response, body := post(t, client, url, "application/grpc-web+proto", grpcWebFrame(t, &api.GetStudentsRequest{PerMessage: 1}), nil)
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d: %s", response.StatusCode, body)
+			}
+
+			messages := parseEnvelopes(t, body)
+			if len(messages) != 2 || messages[0].flags != 0 {
+				t.Fatalf("body = %q, want a message and trailers", body)
+			}
+			var students api.GetStudentsResponse
+			if err := proto.Unmarshal(messages[0].data, &students); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if len(students.Students) != 1 || students.Students[0].Name != "Ada Lovelace" {
+				t.Errorf("message = %v, want Ada Lovelace", &students)
+			}
+			checkGRPCWebTrailers(t, messages[1])
+		})
+
+		t.Run("Connect over "+name, func(t *testing.T) {
+			response, body := post(t, client, url, "application/connect+json", envelope(0, []byte(`{"per_message": 1}`)), nil)
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d: %s", response.StatusCode, body)
+			}
+
+			messages := parseEnvelopes(t, body)
+			if len(messages) != 2 || messages[0].flags != 0 {
+				t.Fatalf("body = %q, want a message and the end of the stream", body)
+			}
+			if !strings.Contains(string(messages[0].data), `"name":"Ada Lovelace"`) {
+				t.Errorf("message = %s, want Ada Lovelace", messages[0].data)
+			}
+			const endStreamFlag = 0x02
+			if end := messages[1]; end.flags&endStreamFlag == 0 || strings.Contains(string(end.data), `"error"`) {
+				t.Errorf("end of stream = %s with flags %#x, want no error", end.data, end.flags)
+			}
+		})
+	}
+}
+
+func TestIsGRPC(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"application/grpc":               true,
+		"application/grpc+proto":         true,
+		"application/grpc;charset=utf-8": true,
+		"application/grpc-web":           false,
+		"application/grpc-web+proto":     false,
+		"application/grpc-web-text":      false,
+		"application/json":               false,
+		"":                               false,
+	} {
+		if got := isGRPC(contentType); got != want {
+			t.Errorf("isGRPC(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
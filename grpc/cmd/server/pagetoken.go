@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Page tokens are opaque to clients. They currently encode the ID of the
+// last returned student or course, prefixed with a version byte so that the format can change.
+const pageTokenVersion = 1
+
+var errInvalidPageToken = errors.New("invalid page token")
+
+func encodePageToken(afterID int32) string {
+	token := binary.AppendUvarint([]byte{pageTokenVersion}, uint64(afterID))
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// decodePageToken returns the ID to continue after. An empty token starts at the beginning.
+func decodePageToken(token string) (int32, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < 2 || data[0] != pageTokenVersion {
+		return 0, errInvalidPageToken
+	}
+
+	afterID, n := binary.Uvarint(data[1:])
+	if n != len(data)-1 || afterID > math.MaxInt32 {
+		return 0, errInvalidPageToken
+	}
+
+	return int32(afterID), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"math"
+	"testing"
+)
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	for _, afterID := range []int32{1, 127, 128, 300, math.MaxInt32} {
+		token := encodePageToken(afterID)
+		got, err := decodePageToken(token)
+		if err != nil {
+			t.Errorf("decodePageToken(%q) = %v", token, err)
+			continue
+		}
+		if got != afterID {
+			t.Errorf("decodePageToken(encodePageToken(%d)) = %d", afterID, got)
+		}
+	}
+}
+
+func TestDecodePageToken(t *testing.T) {
+	raw := func(data ...byte) string {
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		afterID int32
+		err     error
+	}{
+		{"empty starts at the beginning", "", 0, nil},
+		{"valid", raw(pageTokenVersion, 42), 42, nil},
+		{"multi-byte uvarint", raw(pageTokenVersion, 0xac, 0x02), 300, nil},
+		{"not base64", "not a token!", 0, errInvalidPageToken},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte{pageTokenVersion, 42}), 0, errInvalidPageToken},
+		{"standard base64 alphabet", base64.RawStdEncoding.EncodeToString([]byte{pageTokenVersion, 0xfb, 0xff, 0x01}), 0, errInvalidPageToken},
+		{"only version", raw(pageTokenVersion), 0, errInvalidPageToken},
+		{"wrong version", raw(pageTokenVersion+1, 42), 0, errInvalidPageToken},
+		{"version zero", raw(0, 42), 0, errInvalidPageToken},
+		{"truncated uvarint", raw(pageTokenVersion, 0xac), 0, errInvalidPageToken},
+		{"trailing bytes", raw(pageTokenVersion, 42, 0), 0, errInvalidPageToken},
+		{"ID out of range", raw(pageTokenVersion, 0x80, 0x80, 0x80, 0x80, 0x08), 0, errInvalidPageToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			afterID, err := decodePageToken(test.token)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("decodePageToken(%q) = %v, want %v", test.token, err, test.err)
+			}
+			if afterID != test.afterID {
+				t.Errorf("decodePageToken(%q) = %d, want %d", test.token, afterID, test.afterID)
+			}
+		})
+	}
+}
+
+func TestInvalidPageTokenIsInvalidArgument(t *testing.T) {
+	list := func(context.Context, int32, int) ([]*api.Student, error) {
+		t.Fatal("listed students despite an invalid page token")
+		return nil, nil
+	}
+	send := func(*api.GetStudentsResponse) error {
+		t.Fatal("sent a message despite an invalid page token")
+		return nil
+	}
+
+	for _, token := range []string{"not a token!", base64.RawURLEncoding.EncodeToString([]byte{pageTokenVersion + 1, 42})} {
+		err := streamStudents(context.Background(), send, list, 0, 0, token)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("streamStudents(page_token %q) = %v, want InvalidArgument", token, err)
+		}
+	}
+
+	server := &coursesServer{}
+	_, err := server.ListCourses(context.Background(), &api.ListCoursesRequest{PageToken: "not a token!"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListCourses(page_token %q) = %v, want InvalidArgument", "not a token!", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errShuttingDown is returned for RPCs that are rejected or cancelled during a shutdown
+var errShuttingDown = status.Error(codes.Unavailable, "server is shutting down")
+
+// forceTimeout is the time RPCs have to return after being cancelled at the end of the grace period
+const forceTimeout = 5 * time.Second
+
+// drain rejects new RPCs once a shutdown has started and cancels active RPCs
+// when the grace period is over, so clients get UNAVAILABLE instead of a reset connection.
+type drain struct {
+	draining atomic.Bool
+	forced   context.Context
+	force    context.CancelFunc
+}
+
+func newDrain() *drain {
+	forced, force := context.WithCancel(context.Background())
+	return &drain{forced: forced, force: force}
+}
+
+func (d *drain) unary(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if d.draining.Load() {
+		return nil, errShuttingDown
+	}
+
+	ctx, cancel := d.context(ctx)
+	defer cancel()
+
+	response, err := handler(ctx, request)
+	return response, d.convert(err)
+}
+
+func (d *drain) stream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if d.draining.Load() {
+		return errShuttingDown
+	}
+
+	ctx, cancel := d.context(stream.Context())
+	defer cancel()
+
+	err := handler(srv, &drainingStream{ServerStream: stream, ctx: ctx, drain: d})
+	return d.convert(err)
+}
+
+// context returns a context for handlers that is cancelled when the grace period is over
+func (d *drain) context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	stop := context.AfterFunc(d.forced, func() {
+		cancel(errShuttingDown)
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// convert replaces the errors of cancelled handlers, e.g. context.Canceled from the storage
+func (d *drain) convert(err error) error {
+	if err != nil && d.forced.Err() != nil {
+		return errShuttingDown
+	}
+	return err
+}
+
+// drainingStream ends the message exchange of a stream when the grace period is over
+type drainingStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	drain *drain
+}
+
+func (s *drainingStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *drainingStream) SendMsg(m any) error {
+	if s.drain.forced.Err() != nil {
+		return errShuttingDown
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *drainingStream) RecvMsg(m any) error {
+	if s.drain.forced.Err() != nil {
+		return errShuttingDown
+	}
+	return s.ServerStream.RecvMsg(m)
+}
+
+// shutdown stops accepting connections and RPCs and waits up to grace for active requests.
+// Then the remaining RPCs are cancelled and, if they do not return, the connections closed.
+func (d *drain) shutdown(grace time.Duration, grpcServer *grpc.Server, servers ...*http.Server) {
+	d.draining.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	// Most HTTP requests are forwarded to the gRPC server and end with their RPCs
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *http.Server) {
+			defer wg.Done()
+			server.Shutdown(ctx)
+			if ctx.Err() == nil {
+				return
+			}
+
+			forceCtx, cancel := context.WithTimeout(context.Background(), forceTimeout)
+			defer cancel()
+			if server.Shutdown(forceCtx) != nil {
+				server.Close()
+			}
+		}(server)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Print("Grace period is over, cancelling active RPCs")
+		d.force()
+
+		select {
+		case <-stopped:
+		case <-time.After(forceTimeout):
+			log.Print("Closing connections of RPCs that did not return")
+			grpcServer.Stop()
+		}
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrainsActiveRPCs(t *testing.T) {
+	d := newDrain()
+	// started is closed when the handler of ImportStudents runs
+	started := make(chan struct{})
+	signal := func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if info.FullMethod == "/StudentsService/ImportStudents" {
+			close(started)
+		}
+		return handler(srv, stream)
+	}
+	s := startTestServer(t,
+		grpc.ChainUnaryInterceptor(d.unary),
+		grpc.ChainStreamInterceptor(d.stream, signal),
+	)
+	client := api.NewStudentsServiceClient(s.dial(t))
+	ctx := context.Background()
+
+	stream, err := client.ImportStudents(ctx)
+	if err != nil {
+		t.Fatalf("ImportStudents: %v", err)
+	}
+	if err := stream.Send(&api.ImportStudentsRequest{Students: []*api.Student{{Name: "Grace Hopper"}}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		d.shutdown(10*time.Second, s.grpc, s.web)
+		close(stopped)
+	}()
+	for !d.draining.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New RPCs are rejected while the import is still active
+	_, err = client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 1})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("GetStudentById while draining: %v, want Unavailable", err)
+	}
+	called := false
+	_, err = d.unary(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+		called = true
+		return nil, nil
+	})
+	if err != errShuttingDown || called {
+		t.Errorf("unary interceptor while draining = %v, handler called: %v, want %v", err, called, errShuttingDown)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("shutdown returned before the active import")
+	default:
+	}
+
+	// The active import finishes within the grace period
+	if err := stream.Send(&api.ImportStudentsRequest{Students: []*api.Student{{Name: "Margaret Hamilton"}}}); err != nil {
+		t.Fatalf("Send while draining: %v", err)
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv while draining: %v", err)
+	}
+	if response.Count != 2 {
+		t.Errorf("Count = %d, want 2", response.Count)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not return after the import finished")
+	}
+	if d.forced.Err() != nil {
+		t.Error("active RPCs were cancelled although they finished within the grace period")
+	}
+}
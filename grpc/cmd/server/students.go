@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
+	"time"
+)
+
+type server struct {
+	api.StudentsServiceServer
+	store storage.Store
+}
+
+func (s *server) GetStudentById(ctx context.Context, request *api.GetStudentByIdRequest) (*api.Student, error) {
+	student, err := s.store.GetStudent(ctx, request.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "student %d not found", request.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not get student: %v", err)
+	}
+
+	student.Courses, err = s.store.ListStudentCourses(ctx, request.Id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.Internal, "could not list courses: %v", err)
+	}
+
+	return student, nil
+}
+
+// defaultPerMessage is used if GetStudentsRequest.per_message is not set
+const defaultPerMessage = 10
+
+func (s *server) GetStudents(request *api.GetStudentsRequest, stream api.StudentsService_GetStudentsServer) error {
+	return streamStudents(stream.Context(), stream.Send, s.store.ListStudents, request.PerMessage, request.PageSize, request.PageToken)
+}
+
+// listStudents returns up to limit students with an ID greater than afterID, ordered by ID
+type listStudents func(ctx context.Context, afterID int32, limit int) ([]*api.Student, error)
+
+// streamStudents implements the pagination contract of GetStudents for any listing of students:
+// It sends perMessage students per message until pageSize students are sent or there are no more students.
+// Every message carries a token to resume the listing after it, except for the last one if there are no more students.
+func streamStudents(ctx context.Context, send func(*api.GetStudentsResponse) error, list listStudents, perMessage, pageSize int32, pageToken string) error {
+	afterID, err := decodePageToken(pageToken)
+	if err != nil {
+		return api.InvalidArgument("page_token", "is not a token returned by a previous response")
+	}
+
+	if perMessage == 0 {
+		perMessage = defaultPerMessage
+	}
+
+	// Number of students left in this page, 0 means unlimited
+	remaining := int(pageSize)
+
+	for {
+		// Simulate network latency, unless the client is gone
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-time.After(time.Second):
+		}
+
+		limit := int(perMessage)
+		if pageSize > 0 && remaining < limit {
+			limit = remaining
+		}
+
+		// Fetch one additional student to find out whether there are more
+		students, err := list(ctx, afterID, limit+1)
+		if errors.Is(err, storage.ErrNotFound) {
+			return status.Error(codes.NotFound, "not found")
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "could not list students: %v", err)
+		}
+
+		more := len(students) > limit
+		if more {
+			students = students[:limit]
+		}
+
+		if len(students) == 0 {
+			// No students after the page token
+			return nil
+		}
+
+		afterID = students[len(students)-1].Id
+		remaining -= len(students)
+
+		response := api.GetStudentsResponse{Students: students}
+		if more {
+			response.NextPageToken = encodePageToken(afterID)
+		}
+
+		if err := send(&response); err != nil {
+			return err
+		}
+
+		if !more || (pageSize > 0 && remaining == 0) {
+			return nil
+		}
+	}
+}
+
+func (s *server) ImportStudents(stream api.StudentsService_ImportStudentsServer) error {
+	var students []*api.Student
+
+	for {
+		message, err := stream.Recv()
+
+		if err == io.EOF {
+			// No more messages on the stream
+			logger(stream.Context()).Debug("Importing students", "count", len(students))
+
+			// Import all students at once, so an interrupted stream imports none of them
+			if err := s.store.ImportStudents(stream.Context(), students); err != nil {
+				return status.Errorf(codes.Internal, "could not import students: %v", err)
+			}
+			studentsImported.WithLabelValues("ImportStudents").Add(float64(len(students)))
+			summary := api.ImportStudentsResponse{Count: int32(len(students))}
+			return stream.SendAndClose(&summary)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Process message
+		time.Sleep(time.Duration(len(message.Students)) * 200 * time.Millisecond)
+
+		students = append(students, message.Students...)
+	}
+}
+
+func (s *server) ImportStudentsV2(stream api.StudentsService_ImportStudentsV2Server) error {
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			// No more incoming messages
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		logger(stream.Context()).Debug("Importing students", "count", len(in.Students))
+
+		// Do some work
+		time.Sleep(500 * time.Millisecond)
+
+		// The repository assigns the IDs
+		if err := s.store.ImportStudents(stream.Context(), in.Students); err != nil {
+			return status.Errorf(codes.Internal, "could not import students: %v", err)
+		}
+		studentsImported.WithLabelValues("ImportStudentsV2").Add(float64(len(in.Students)))
+
+		message := api.ImportStudentsV2Response{Students: in.Students}
+		if err := stream.Send(&message); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) CreateStudent(ctx context.Context, request *api.CreateStudentRequest) (*api.Student, error) {
+	student := request.Student
+	if err := s.store.ImportStudents(ctx, []*api.Student{student}); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not create student: %v", err)
+	}
+	studentsImported.WithLabelValues("CreateStudent").Inc()
+
+	return student, nil
+}
+
+func (s *server) UpdateStudent(ctx context.Context, request *api.UpdateStudentRequest) (*api.Student, error) {
+	fields, err := request.UpdatedFields()
+	if err != nil {
+		return nil, err
+	}
+
+	student, err := s.store.UpdateStudent(ctx, request.Student.Id, func(student *api.Student) error {
+		api.CopyFields(student, request.Student, fields)
+		return nil
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "student %d not found", request.Student.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not update student: %v", err)
+	}
+
+	return student, nil
+}
+
+func (s *server) DeleteStudent(ctx context.Context, request *api.DeleteStudentRequest) (*emptypb.Empty, error) {
+	err := s.store.DeleteStudent(ctx, request.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "student %d not found", request.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not delete student: %v", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"testing"
+	"time"
+)
+
+// studentsClient returns a client of a test server that validates requests like main
+func studentsClient(t *testing.T) api.StudentsServiceClient {
+	t.Helper()
+	s := startTestServer(t, grpc.ChainUnaryInterceptor(validateUnary))
+	return api.NewStudentsServiceClient(s.dial(t))
+}
+
+func TestCreateStudent(t *testing.T) {
+	client := studentsClient(t)
+	ctx := context.Background()
+
+	for _, want := range []int32{2, 3} {
+		student, err := client.CreateStudent(ctx, &api.CreateStudentRequest{Student: &api.Student{Name: "Grace Hopper"}})
+		if err != nil {
+			t.Fatalf("CreateStudent: %v", err)
+		}
+		if student.Id != want || student.Name != "Grace Hopper" {
+			t.Errorf("CreateStudent = %v, want ID %d", student, want)
+		}
+
+		stored, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: student.Id})
+		if err != nil {
+			t.Fatalf("GetStudentById(%d): %v", student.Id, err)
+		}
+		if stored.Name != "Grace Hopper" {
+			t.Errorf("GetStudentById(%d) = %v, want Grace Hopper", student.Id, stored)
+		}
+	}
+
+	// The ID is assigned by the server
+	student, err := client.CreateStudent(ctx, &api.CreateStudentRequest{Student: &api.Student{Id: 1, Name: "Ada"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateStudent with an ID = %v, %v, want InvalidArgument", student, err)
+	}
+}
+
+func TestUpdateStudent(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *api.UpdateStudentRequest
+		want     string
+		wantCode codes.Code
+	}{
+		{
+			name:    "name in mask",
+			request: &api.UpdateStudentRequest{Student: &api.Student{Id: 1, Name: "Augusta Ada King"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}},
+			want:    "Augusta Ada King",
+		},
+		{
+			name:    "no mask",
+			request: &api.UpdateStudentRequest{Student: &api.Student{Id: 1, Name: "Augusta Ada King"}},
+			want:    "Augusta Ada King",
+		},
+		{
+			name:     "unknown field in mask",
+			request:  &api.UpdateStudentRequest{Student: &api.Student{Id: 1, Name: "Augusta Ada King"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "immutable field in mask",
+			request:  &api.UpdateStudentRequest{Student: &api.Student{Id: 1}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"courses"}}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "unknown student",
+			request:  &api.UpdateStudentRequest{Student: &api.Student{Id: 2, Name: "Grace Hopper"}},
+			wantCode: codes.NotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := studentsClient(t)
+			ctx := context.Background()
+
+			student, err := client.UpdateStudent(ctx, test.request)
+			if status.Code(err) != test.wantCode {
+				t.Fatalf("UpdateStudent = %v, want %s", err, test.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if student.Id != 1 || student.Name != test.want {
+				t.Errorf("UpdateStudent = %v, want student 1 named %s", student, test.want)
+			}
+
+			stored, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 1})
+			if err != nil {
+				t.Fatalf("GetStudentById: %v", err)
+			}
+			if stored.Name != test.want {
+				t.Errorf("stored student = %v, want %s", stored, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdateStudentKeepsImmutableFields(t *testing.T) {
+	s := startTestServer(t, grpc.ChainUnaryInterceptor(validateUnary))
+	conn := s.dial(t)
+	client := api.NewStudentsServiceClient(conn)
+	ctx := context.Background()
+
+	if _, err := api.NewCoursesServiceClient(conn).Enroll(ctx, &api.EnrollRequest{StudentId: 1, CourseId: 1}); err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+
+	// Without a mask all fields are updated except the ID and the courses
+	if _, err := client.UpdateStudent(ctx, &api.UpdateStudentRequest{Student: &api.Student{Id: 1, Name: "Augusta Ada King"}}); err != nil {
+		t.Fatalf("UpdateStudent: %v", err)
+	}
+
+	stored, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 1})
+	if err != nil {
+		t.Fatalf("GetStudentById: %v", err)
+	}
+	if stored.Name != "Augusta Ada King" || len(stored.Courses) != 1 || stored.Courses[0].Name != "Cloud Computing" {
+		t.Errorf("stored student = %v, want Augusta Ada King enrolled in Cloud Computing", stored)
+	}
+}
+
+func TestDeleteStudent(t *testing.T) {
+	client := studentsClient(t)
+	ctx := context.Background()
+
+	if _, err := client.DeleteStudent(ctx, &api.DeleteStudentRequest{Id: 1}); err != nil {
+		t.Fatalf("DeleteStudent: %v", err)
+	}
+	if _, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 1}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetStudentById of a deleted student = %v, want NotFound", err)
+	}
+	if _, err := client.DeleteStudent(ctx, &api.DeleteStudentRequest{Id: 1}); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteStudent of a deleted student = %v, want NotFound", err)
+	}
+
+	// IDs are not reused
+	student, err := client.CreateStudent(ctx, &api.CreateStudentRequest{Student: &api.Student{Name: "Grace Hopper"}})
+	if err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	if student.Id != 2 {
+		t.Errorf("CreateStudent after DeleteStudent = %v, want ID 2", student)
+	}
+}
+
+func TestGetStudentsStopsWhenCancelled(t *testing.T) {
+	// returned receives the error of the handler of GetStudents
+	returned := make(chan error, 1)
+	record := func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, stream)
+		if info.FullMethod == "/StudentsService/GetStudents" {
+			returned <- err
+		}
+		return err
+	}
+	s := startTestServer(t, grpc.ChainStreamInterceptor(record))
+	client := api.NewStudentsServiceClient(s.dial(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.GetStudents(ctx, &api.GetStudentsRequest{})
+	if err != nil {
+		t.Fatalf("GetStudents: %v", err)
+	}
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("Recv = %v, want Canceled", err)
+	}
+
+	// The handler returns before the simulated latency of a second is over
+	select {
+	case err := <-returned:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("handler returned %v, want Canceled", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("the handler is still running after the client cancelled")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// startHTTP serves handler on address in the background, with TLS if tlsConfig is not nil
+func startHTTP(name, address string, handler http.Handler, tlsConfig *tls.Config) *http.Server {
+	server := &http.Server{Addr: address, Handler: handler, TLSConfig: tlsConfig}
+
+	go func() {
+		log.Printf("Serving %s on %s", name, address)
+
+		var err error
+		if tlsConfig != nil {
+			// The certificates are provided by tlsConfig
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error starting %s: %v", name, err)
+		}
+	}()
+
+	return server
+}
+
+// withoutClientAuth returns tlsConfig without mutual TLS. Client certificates are only required
+// on the gRPC listener, the REST API, the gateway and the metrics endpoint serve standard
+// HTTP clients such as Prometheus.
+func withoutClientAuth(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		return nil
+	}
+	c := tlsConfig.Clone()
+	c.ClientAuth = tls.NoClientCert
+	// Only sets the reloaded CAs of client certificates
+	c.GetConfigForClient = nil
+	return c
+}
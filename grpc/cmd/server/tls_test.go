@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/config"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed certificate for localhost
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestServeProtocolsWithTLS(t *testing.T) {
+	certificate := testCertificate(t)
+	s := startTLSTestServer(t, &tls.Config{Certificates: []tls.Certificate{certificate}})
+
+	roots := x509.NewCertPool()
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots.AddCert(leaf)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+
+	t.Run("gRPC", func(t *testing.T) {
+		conn := s.dial(t, grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+		student, err := api.NewStudentsServiceClient(conn).GetStudentById(context.Background(), &api.GetStudentByIdRequest{Id: 1})
+		if err != nil {
+			t.Fatalf("GetStudentById: %v", err)
+		}
+		if student.Name != "Ada Lovelace" {
+			t.Errorf("GetStudentById = %v, want Ada Lovelace", student)
+		}
+	})
+
+	// Browsers negotiate HTTP/2 or fall back to HTTP/1.1
+	tests := []struct {
+		name      string
+		transport http.RoundTripper
+		major     int
+	}{
+		{"HTTP/1.1", &http.Transport{TLSClientConfig: clientConfig}, 1},
+		{"HTTP/2", &http2.Transport{TLSClientConfig: clientConfig}, 2},
+	}
+	for _, test := range tests {
+		t.Run("Connect over "+test.name, func(t *testing.T) {
+			url := "https://" + s.address + "/StudentsService/GetStudentById"
+			response, body := post(t, &http.Client{Transport: test.transport}, url, "application/json", []byte(`{"id": 1}`), http.Header{"Connect-Protocol-Version": {"1"}})
+			if response.StatusCode != http.StatusOK || response.ProtoMajor != test.major {
+				t.Fatalf("status = %d over %s: %s", response.StatusCode, response.Proto, body)
+			}
+			if !strings.Contains(string(body), `"name":"Ada Lovelace"`) {
+				t.Errorf("body = %s, want Ada Lovelace", body)
+			}
+		})
+	}
+}
+
+// handshake reports the error of a TLS handshake between server and a client without certificate
+func handshake(t *testing.T, server *tls.Config, roots *x509.CertPool) error {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go tls.Server(serverConn, server).Handshake()
+	client := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	if err := client.Handshake(); err != nil {
+		return err
+	}
+	// With TLS 1.3 the client learns about a rejected certificate on its first read
+	client.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, err := client.Read(make([]byte, 1))
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return nil
+	}
+	return err
+}
+
+func TestWithoutClientAuth(t *testing.T) {
+	certificate := testCertificate(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	keyDER, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The self-signed certificate also serves as CA of client certificates
+	settings := config.ServerTLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile}
+	mutual, err := settings.Config()
+	if err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+	roots := x509.NewCertPool()
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots.AddCert(leaf)
+
+	if err := handshake(t, mutual, roots); err == nil {
+		t.Error("gRPC listener accepts clients without certificate")
+	}
+	if err := handshake(t, withoutClientAuth(mutual), roots); err != nil {
+		t.Errorf("HTTP listeners reject clients without certificate: %v", err)
+	}
+	if mutual.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Error("withoutClientAuth changed the config of the gRPC listener")
+	}
+	if withoutClientAuth(nil) != nil {
+		t.Error("withoutClientAuth(nil) enables TLS")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by the server, the RPC spans are created by otelgrpc
+const tracerName = "github.com/simonhammes/301-cloud-computing-project/grpc/cmd/server"
+
+// traceStorage returns an observer that records storage operations as child spans of the RPC.
+// Operations outside of traces, e.g. health checks, are not recorded.
+func traceStorage(backend string) storage.Observer {
+	return func(ctx context.Context, operation string) (context.Context, func(err error)) {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return ctx, func(error) {}
+		}
+
+		ctx, span := otel.Tracer(tracerName).Start(ctx, "storage."+operation,
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(
+				attribute.String("db.system", backend),
+				attribute.String("db.operation", operation),
+			),
+		)
+
+		return ctx, func(err error) {
+			result := storageResult(err)
+			span.SetAttributes(attribute.String("storage.result", result))
+			if err != nil {
+				span.RecordError(err)
+			}
+			if result == "error" {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"sort"
+	"testing"
+	"time"
+)
+
+// recordSpans makes the global tracer provider record all spans in memory and propagates
+// the trace context like config.Tracing
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return recorder
+}
+
+// traced starts a trace and returns a context that sends its trace context to the server, like a traced client
+func traced(t *testing.T) (context.Context, trace.SpanContext) {
+	t.Helper()
+	ctx, span := otel.Tracer(tracerName).Start(context.Background(), t.Name(), trace.WithSpanKind(trace.SpanKindClient))
+	span.End()
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return metadata.NewOutgoingContext(context.Background(), metadata.New(carrier)), span.SpanContext()
+}
+
+// serverSpan waits for the server span of the trace and returns it with its children, ordered by their start.
+// otelgrpc ends the server span after the response is sent, so it may end after the client received it.
+func serverSpan(t *testing.T, recorder *tracetest.SpanRecorder, traceID trace.TraceID) (sdktrace.ReadOnlySpan, []sdktrace.ReadOnlySpan) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		spans := recorder.Ended()
+		var server sdktrace.ReadOnlySpan
+		for _, span := range spans {
+			if span.SpanContext().TraceID() == traceID && span.SpanKind() == trace.SpanKindServer {
+				server = span
+			}
+		}
+		if server != nil {
+			var children []sdktrace.ReadOnlySpan
+			for _, span := range spans {
+				if span.Parent().SpanID() == server.SpanContext().SpanID() {
+					children = append(children, span)
+				}
+			}
+			sort.Slice(children, func(i, j int) bool { return children[i].StartTime().Before(children[j].StartTime()) })
+			return server, children
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no server span in trace %s", traceID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// attributes returns the attributes of span by their key
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attributes := make(map[attribute.Key]string)
+	for _, attribute := range span.Attributes() {
+		attributes[attribute.Key] = attribute.Value.Emit()
+	}
+	return attributes
+}
+
+func TestTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	memory := storage.NewMemory()
+	t.Cleanup(func() { memory.Close() })
+	if err := memory.ImportStudents(context.Background(), []*api.Student{{Name: "Ada Lovelace"}}); err != nil {
+		t.Fatalf("ImportStudents: %v", err)
+	}
+	_, conn := startHealthServer(t, storage.Observe(memory, traceStorage("memory")),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
+	client := api.NewStudentsServiceClient(conn)
+
+	t.Run("storage spans", func(t *testing.T) {
+		ctx, caller := traced(t)
+		if _, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 1}); err != nil {
+			t.Fatalf("GetStudentById: %v", err)
+		}
+
+		server, children := serverSpan(t, recorder, caller.TraceID())
+		if server.Name() != "StudentsService/GetStudentById" {
+			t.Errorf("server span = %s, want StudentsService/GetStudentById", server.Name())
+		}
+		// The server continues the trace of the caller
+		if server.Parent().SpanID() != caller.SpanID() {
+			t.Errorf("parent of the server span = %s, want the span of the caller %s", server.Parent().SpanID(), caller.SpanID())
+		}
+
+		want := []string{"GetStudent", "ListStudentCourses"}
+		if len(children) != len(want) {
+			t.Fatalf("child spans = %v, want storage spans of %v", children, want)
+		}
+		for i, operation := range want {
+			child := children[i]
+			if child.Name() != "storage."+operation || child.SpanKind() != trace.SpanKindInternal {
+				t.Errorf("child span %d = %s of kind %s, want an internal span storage.%s", i, child.Name(), child.SpanKind(), operation)
+			}
+			if got := attributes(child); got["db.system"] != "memory" || got["db.operation"] != operation || got["storage.result"] != "ok" {
+				t.Errorf("attributes of %s = %v, want the memory backend, the operation and an ok result", child.Name(), got)
+			}
+		}
+	})
+
+	t.Run("rejected operation", func(t *testing.T) {
+		ctx, caller := traced(t)
+		if _, err := client.GetStudentById(ctx, &api.GetStudentByIdRequest{Id: 42}); err == nil {
+			t.Fatal("GetStudentById of an unknown student succeeded")
+		}
+
+		_, children := serverSpan(t, recorder, caller.TraceID())
+		if len(children) != 1 || children[0].Name() != "storage.GetStudent" {
+			t.Fatalf("child spans = %v, want one of GetStudent", children)
+		}
+		child := children[0]
+		if got := attributes(child)["storage.result"]; got != "rejected" {
+			t.Errorf("storage.result = %s, want rejected", got)
+		}
+		// Expected outcomes are recorded, but are no failures of the storage
+		if len(child.Events()) != 1 || child.Events()[0].Name != "exception" {
+			t.Errorf("events = %v, want the recorded error", child.Events())
+		}
+		if child.Status().Code != codes.Unset {
+			t.Errorf("status = %v, want unset", child.Status())
+		}
+	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		ctx, parent := otel.Tracer(tracerName).Start(context.Background(), t.Name())
+		defer parent.End()
+		_, done := traceStorage("memory")(ctx, "ListStudents")
+		done(errUnreachable)
+
+		ended := recorder.Ended()
+		child := ended[len(ended)-1]
+		if child.Name() != "storage.ListStudents" || child.Status().Code != codes.Error || child.Status().Description != errUnreachable.Error() {
+			t.Errorf("span = %s with status %v, want storage.ListStudents with the error", child.Name(), child.Status())
+		}
+		if got := attributes(child)["storage.result"]; got != "error" {
+			t.Errorf("storage.result = %s, want error", got)
+		}
+	})
+
+	t.Run("outside of traces", func(t *testing.T) {
+		started := len(recorder.Started())
+		if _, err := storage.Observe(memory, traceStorage("memory")).ListStudents(context.Background(), 0, 1); err != nil {
+			t.Fatalf("ListStudents: %v", err)
+		}
+		if spans := recorder.Started()[started:]; len(spans) != 0 {
+			t.Errorf("started spans = %v, want none", spans)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/grpc"
+)
+
+// validateUnary rejects invalid requests before they reach the handler
+func validateUnary(ctx context.Context, request any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if v, ok := request.(api.Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return handler(ctx, request)
+}
+
+// validateStream validates every message received on a stream.
+// An invalid message fails the Recv call and therefore ends the stream,
+// messages received before are processed normally.
+func validateStream(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatingStream{stream})
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if v, ok := m.(api.Validator); ok {
+		return v.Validate()
+	}
+
+	return nil
+}
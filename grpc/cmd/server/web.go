@@ -0,0 +1,116 @@
+package main
+
+import (
+	"connectrpc.com/vanguard"
+	"context"
+	"crypto/tls"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+)
+
+// Headers of the gRPC-Web and Connect protocols that browsers must be allowed to send and read,
+// plus the bearer token, request ID and trace context that are passed on to the RPCs
+var (
+	corsRequestHeaders = []string{
+		"Content-Type", "Connect-Protocol-Version", "Connect-Timeout-Ms", "Connect-Accept-Encoding",
+		"Connect-Content-Encoding", "Grpc-Timeout", "X-Grpc-Web", "X-User-Agent",
+		"Authorization", "X-Request-Id", "Traceparent", "Tracestate",
+	}
+	corsResponseHeaders = []string{
+		"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin", "Connect-Content-Encoding",
+		"Connect-Accept-Encoding", "X-Request-Id",
+	}
+)
+
+// newWebHandler passes native gRPC requests to the services of grpcServer over connections opened
+// by dial, and translates gRPC-Web and Connect requests to gRPC on the way. Using connections
+// instead of grpc.Server.ServeHTTP lets GracefulStop drain these requests like the gateway's.
+// Unary Connect requests need the Connect-Protocol-Version header, which generated clients
+// always send.
+func newWebHandler(grpcServer *grpc.Server, dial dialer, allowedOrigins []string) (http.Handler, error) {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = "http"
+			r.Out.URL.Host = "internal"
+			// For the logs of the gRPC server
+			r.SetXForwarded()
+		},
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, _, _ string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx)
+			},
+		},
+		// Stream responses
+		FlushInterval: -1,
+	}
+
+	var services []*vanguard.Service
+	for name := range grpcServer.GetServiceInfo() {
+		services = append(services, vanguard.NewService(name, proxy))
+	}
+	transcoder, err := vanguard.NewTranscoder(services, vanguard.WithDefaultServiceOptions(
+		vanguard.WithTargetCodecs(vanguard.CodecProto),
+		vanguard.WithTargetProtocols(vanguard.ProtocolGRPC),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var web http.Handler = transcoder
+	if len(allowedOrigins) > 0 {
+		web = cors(web, allowedOrigins)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && isGRPC(r.Header.Get("Content-Type")) {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		web.ServeHTTP(w, r)
+	}), nil
+}
+
+// cors allows browsers on the given origins, or any origin for "*", to call the services
+func cors(next http.Handler, allowedOrigins []string) http.Handler {
+	allowed := originAllowed(allowedOrigins)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !allowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsResponseHeaders, ", "))
+
+		// Preflight request
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsRequestHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", "7200")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed returns whether browsers on an origin may call the services, given the
+// allowed origins of the -cors flag
+func originAllowed(allowedOrigins []string) func(origin string) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+	return func(origin string) bool {
+		return allowed[origin] || allowed["*"]
+	}
+}
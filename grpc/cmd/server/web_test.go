@@ -0,0 +1,56 @@
+package main
+
+import (
+	"google.golang.org/grpc"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCORSAllowsForwardedHeaders(t *testing.T) {
+	s := startTestServer(t)
+	url := "http://" + s.address + "/StudentsService/GetStudentById"
+
+	for _, header := range []string{"authorization", "x-request-id", "traceparent", "tracestate", "connect-protocol-version"} {
+		t.Run(header, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodOptions, url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Origin", "https://example.com")
+			request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			request.Header.Set("Access-Control-Request-Headers", "content-type,"+header)
+
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("OPTIONS: %v", err)
+			}
+			response.Body.Close()
+
+			allowed := strings.Split(strings.ToLower(response.Header.Get("Access-Control-Allow-Headers")), ", ")
+			if !slices.Contains(allowed, header) {
+				t.Errorf("Access-Control-Allow-Headers = %v, want %s", allowed, header)
+			}
+		})
+	}
+}
+
+func TestBrowserClientsAuthenticate(t *testing.T) {
+	issuer := newTestIssuer(t)
+	authn := &authenticator{verifier: issuer.verifier, audit: newTestAudit().logger}
+	s := startTestServer(t, grpc.ChainUnaryInterceptor(authn.unary))
+	url := "http://" + s.address + "/StudentsService/GetStudentById"
+
+	header := http.Header{"Connect-Protocol-Version": {"1"}, "Origin": {"https://example.com"}}
+	response, body := post(t, http.DefaultClient, url, "application/json", []byte(`{"id": 1}`), header)
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without token: status = %d, body = %s, want %d", response.StatusCode, body, http.StatusUnauthorized)
+	}
+
+	header.Set("Authorization", "Bearer "+issuer.token(t, "ada"))
+	response, body = post(t, http.DefaultClient, url, "application/json", []byte(`{"id": 1}`), header)
+	if response.StatusCode != http.StatusOK || !strings.Contains(string(body), `"name":"Ada Lovelace"`) {
+		t.Errorf("with token: status = %d, body = %s, want Ada Lovelace", response.StatusCode, body)
+	}
+}
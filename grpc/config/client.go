@@ -0,0 +1,62 @@
+package config
+
+import (
+	"flag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"math"
+	"time"
+)
+
+// Client holds the transport settings of gRPC clients
+type Client struct {
+	// Address is a gRPC target like host:port or unix:///path/to/socket
+	Address string
+
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+
+	Keepalive keepalive.ClientParameters
+
+	TLS ClientTLS
+
+	// Token is a JWT sent as bearer token with every RPC
+	Token string
+}
+
+// RegisterFlags adds the flags of the settings to fs, with gRPC's defaults
+func (c *Client) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Address, "address", "127.0.0.1:3000", "server address, host:port or unix:///path/to/socket")
+	fs.IntVar(&c.MaxRecvMsgSize, "max-recv-msg-size", 4*1024*1024, "maximum size of received messages in bytes")
+	fs.IntVar(&c.MaxSendMsgSize, "max-send-msg-size", math.MaxInt32, "maximum size of sent messages in bytes")
+
+	fs.DurationVar(&c.Keepalive.Time, "keepalive-time", 0, "ping the server after this duration of inactivity, 0 to disable")
+	fs.DurationVar(&c.Keepalive.Timeout, "keepalive-timeout", 20*time.Second, "close the connection if a ping is not answered within this duration")
+	fs.BoolVar(&c.Keepalive.PermitWithoutStream, "keepalive-permit-without-stream", false, "ping without active streams")
+
+	c.TLS.RegisterFlags(fs)
+	fs.StringVar(&c.Token, "token", "", "JWT sent as bearer token with every RPC, better set via "+EnvName(ClientEnvPrefix, "token"))
+}
+
+// DialOptions returns the gRPC dial options for the settings
+func (c *Client) DialOptions() ([]grpc.DialOption, error) {
+	creds, err := c.TLS.Credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(c.MaxRecvMsgSize),
+			grpc.MaxCallSendMsgSize(c.MaxSendMsgSize),
+		),
+	}
+	if c.Keepalive.Time > 0 {
+		options = append(options, grpc.WithKeepaliveParams(c.Keepalive))
+	}
+	if c.Token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(bearerToken(c.Token)))
+	}
+	return options, nil
+}
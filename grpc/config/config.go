@@ -0,0 +1,94 @@
+// Package config loads command-line flags from a configuration file and environment variables
+// and holds the transport settings shared by the server and client commands.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// Prefixes of the environment variables of the commands, e.g. STUDENTS_SERVER_LISTEN for
+// -listen of the server. Each command has its own, so variables exported for the server
+// do not configure a client started in the same shell.
+const (
+	ServerEnvPrefix = "STUDENTS_SERVER_"
+	ClientEnvPrefix = "STUDENTS_CLIENT_"
+)
+
+// Load parses the command line arguments into fs. Flags that are not set on the command line
+// are taken from environment variables starting with prefix, then from the YAML file given by
+// -config or the CONFIG variable, and otherwise keep their defaults. The file maps flag names
+// to values:
+//
+//	listen: unix:///run/students/a.sock
+//	max-recv-msg-size: 8388608
+//	keepalive-time: 1m
+func Load(fs *flag.FlagSet, prefix string, args []string) error {
+	path := fs.String("config", "", "YAML file with flag values, env "+EnvName(prefix, "config"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	if !set["config"] {
+		*path = os.Getenv(EnvName(prefix, "config"))
+	}
+	if *path != "" {
+		if err := loadFile(fs, *path, set); err != nil {
+			return err
+		}
+	}
+
+	// Environment variables take precedence over the file, so apply them last
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(EnvName(prefix, f.Name))
+		if !ok || set[f.Name] || f.Name == "config" || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %v", value, EnvName(prefix, f.Name), setErr)
+		}
+	})
+
+	return err
+}
+
+// EnvName returns the environment variable for a flag of the command with prefix
+func EnvName(prefix, flag string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+func loadFile(fs *flag.FlagSet, path string, set map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	for name, value := range values {
+		f := fs.Lookup(name)
+		if f == nil || name == "config" {
+			return fmt.Errorf("%s: unknown setting %q", path, name)
+		}
+		if set[name] {
+			continue
+		}
+		if err := f.Value.Set(fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("%s: invalid value %v for %s: %v", path, value, name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package config_test
+
+import (
+	"flag"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type settings struct {
+	listen    string
+	size      int
+	keepalive time.Duration
+	insecure  bool
+}
+
+func newFlagSet(s *settings) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&s.listen, "listen", "127.0.0.1:3000", "")
+	fs.IntVar(&s.size, "max-recv-msg-size", 4096, "")
+	fs.DurationVar(&s.keepalive, "keepalive-time", 2*time.Hour, "")
+	fs.BoolVar(&s.insecure, "insecure", false, "")
+	return fs
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "listen: file:3000\nmax-recv-msg-size: 8388608\nkeepalive-time: 1m\ninsecure: true\n")
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want settings
+	}{
+		{
+			name: "defaults",
+			want: settings{"127.0.0.1:3000", 4096, 2 * time.Hour, false},
+		},
+		{
+			name: "file",
+			args: []string{"-config", file},
+			want: settings{"file:3000", 8388608, time.Minute, true},
+		},
+		{
+			name: "file from the environment",
+			env:  map[string]string{"STUDENTS_SERVER_CONFIG": file},
+			want: settings{"file:3000", 8388608, time.Minute, true},
+		},
+		{
+			name: "environment over file",
+			args: []string{"-config", file},
+			env:  map[string]string{"STUDENTS_SERVER_LISTEN": "env:3000", "STUDENTS_SERVER_KEEPALIVE_TIME": "30s", "STUDENTS_SERVER_INSECURE": "false"},
+			want: settings{"env:3000", 8388608, 30 * time.Second, false},
+		},
+		{
+			name: "flags over environment and file",
+			args: []string{"-config", file, "-listen", "flag:3000", "-max-recv-msg-size", "1024"},
+			env:  map[string]string{"STUDENTS_SERVER_LISTEN": "env:3000", "STUDENTS_SERVER_MAX_RECV_MSG_SIZE": "2048"},
+			want: settings{"flag:3000", 1024, time.Minute, true},
+		},
+		{
+			name: "-config over STUDENTS_SERVER_CONFIG",
+			args: []string{"-config", file},
+			env:  map[string]string{"STUDENTS_SERVER_CONFIG": filepath.Join(t.TempDir(), "missing.yaml")},
+			want: settings{"file:3000", 8388608, time.Minute, true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			var got settings
+			if err := config.Load(newFlagSet(&got), config.ServerEnvPrefix, test.args); err != nil {
+				t.Fatalf("Load(%v) = %v", test.args, err)
+			}
+			if got != test.want {
+				t.Errorf("Load(%v) = %+v, want %+v", test.args, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		err  string
+	}{
+		{"unknown setting", "port: 3000\n", nil, `unknown setting "port"`},
+		{"config in file", "config: other.yaml\n", nil, `unknown setting "config"`},
+		{"invalid value in file", "keepalive-time: soon\n", nil, "invalid value soon for keepalive-time"},
+		{"invalid YAML", "listen: [\n", nil, "config.yaml"},
+		{"invalid value in environment", "", map[string]string{"STUDENTS_SERVER_MAX_RECV_MSG_SIZE": "big"}, `invalid value "big" for STUDENTS_SERVER_MAX_RECV_MSG_SIZE`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			var s settings
+			err := config.Load(newFlagSet(&s), config.ServerEnvPrefix, []string{"-config", writeFile(t, test.file)})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Load() = %v, want an error containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLoadIgnoresOtherCommands(t *testing.T) {
+	// Exported for the server in the same shell
+	t.Setenv("STUDENTS_SERVER_LISTEN", "server:3000")
+	t.Setenv("STUDENTS_SERVER_CONFIG", writeFile(t, "insecure: true\n"))
+	t.Setenv("STUDENTS_CLIENT_MAX_RECV_MSG_SIZE", "2048")
+
+	var got settings
+	if err := config.Load(newFlagSet(&got), config.ClientEnvPrefix, nil); err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if want := (settings{"127.0.0.1:3000", 2048, 2 * time.Hour, false}); got != want {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{config.ServerEnvPrefix, "STUDENTS_SERVER_MAX_RECV_MSG_SIZE"},
+		{config.ClientEnvPrefix, "STUDENTS_CLIENT_MAX_RECV_MSG_SIZE"},
+	}
+	for _, test := range tests {
+		if got := config.EnvName(test.prefix, "max-recv-msg-size"); got != test.want {
+			t.Errorf("EnvName(%s, max-recv-msg-size) = %s, want %s", test.prefix, got, test.want)
+		}
+	}
+}
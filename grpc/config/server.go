@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Server holds the transport settings of the gRPC server
+type Server struct {
+	// Address is host:port or unix:///path/to/socket
+	Address string
+
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	// MaxConcurrentStreams limits the streams per connection, 0 means unlimited
+	MaxConcurrentStreams uint
+
+	Keepalive       keepalive.ServerParameters
+	KeepalivePolicy keepalive.EnforcementPolicy
+
+	TLS ServerTLS
+}
+
+// RegisterFlags adds the flags of the settings to fs, with gRPC's defaults
+func (s *Server) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&s.Address, "listen", "127.0.0.1:3000", "listen address, host:port or unix:///path/to/socket")
+	fs.IntVar(&s.MaxRecvMsgSize, "max-recv-msg-size", 4*1024*1024, "maximum size of received messages in bytes")
+	fs.IntVar(&s.MaxSendMsgSize, "max-send-msg-size", math.MaxInt32, "maximum size of sent messages in bytes")
+	fs.UintVar(&s.MaxConcurrentStreams, "max-concurrent-streams", 0, "maximum number of concurrent streams per connection, 0 for unlimited")
+
+	fs.DurationVar(&s.Keepalive.Time, "keepalive-time", 2*time.Hour, "ping clients after this duration of inactivity")
+	fs.DurationVar(&s.Keepalive.Timeout, "keepalive-timeout", 20*time.Second, "close connections if a ping is not answered within this duration")
+	fs.DurationVar(&s.Keepalive.MaxConnectionIdle, "keepalive-max-connection-idle", 0, "close connections without RPCs after this duration, 0 for never")
+	fs.DurationVar(&s.Keepalive.MaxConnectionAge, "keepalive-max-connection-age", 0, "close connections after this duration, 0 for never")
+	fs.DurationVar(&s.Keepalive.MaxConnectionAgeGrace, "keepalive-max-connection-age-grace", 0, "time for pending RPCs after the maximum connection age, 0 for unlimited")
+	fs.DurationVar(&s.KeepalivePolicy.MinTime, "keepalive-min-time", 5*time.Minute, "minimum interval of client pings")
+	fs.BoolVar(&s.KeepalivePolicy.PermitWithoutStream, "keepalive-permit-without-stream", false, "allow client pings without active streams")
+
+	s.TLS.RegisterFlags(fs)
+}
+
+// Options returns the gRPC server options for the settings
+func (s *Server) Options() []grpc.ServerOption {
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(s.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(s.MaxSendMsgSize),
+		grpc.KeepaliveParams(s.Keepalive),
+		grpc.KeepaliveEnforcementPolicy(s.KeepalivePolicy),
+	}
+	if s.MaxConcurrentStreams > 0 {
+		options = append(options, grpc.MaxConcurrentStreams(uint32(s.MaxConcurrentStreams)))
+	}
+	return options
+}
+
+// Listen opens the listener for Address. A stale Unix domain socket of a previous run
+// is removed, but not one that is still in use by another instance.
+func (s *Server) Listen() (net.Listener, error) {
+	network, address := SplitAddress(s.Address)
+	if network == "unix" {
+		if conn, err := net.Dial("unix", address); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use", address)
+		}
+		if err := os.Remove(address); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return net.Listen(network, address)
+}
+
+// SplitAddress returns the network and address for net.Listen and net.Dial
+func SplitAddress(address string) (string, string) {
+	for _, prefix := range []string{"unix://", "unix:"} {
+		if path, ok := strings.CutPrefix(address, prefix); ok {
+			return "unix", path
+		}
+	}
+	return "tcp", address
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"os"
+	"sync"
+	"time"
+)
+
+// ServerTLS holds the certificate of the server and, for mutual TLS, the CA of client certificates.
+// The files are reloaded when they change, so rotated certificates are used without a restart.
+type ServerTLS struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+// RegisterFlags adds the flags of the settings to fs
+func (t *ServerTLS) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&t.CertFile, "tls-cert", "", "PEM certificate of the server, enables TLS")
+	fs.StringVar(&t.KeyFile, "tls-key", "", "PEM private key of the server certificate")
+	fs.StringVar(&t.ClientCAFile, "tls-client-ca", "", "PEM CA certificates to verify clients with, enables mutual TLS on the gRPC listener")
+}
+
+// Config returns the TLS configuration of the server, or nil if TLS is disabled
+func (t *ServerTLS) Config() (*tls.Config, error) {
+	if t.CertFile == "" && t.KeyFile == "" {
+		if t.ClientCAFile != "" {
+			return nil, errors.New("-tls-client-ca requires -tls-cert and -tls-key")
+		}
+		return nil, nil
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, errors.New("-tls-cert and -tls-key must be set together")
+	}
+
+	certificate := newReloading(keyPairLoader(t.CertFile, t.KeyFile), t.CertFile, t.KeyFile)
+	if _, err := certificate.get(); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certificate.get()
+		},
+	}
+	if t.ClientCAFile == "" {
+		return config, nil
+	}
+
+	clientCAs := newReloading(certPoolLoader(t.ClientCAFile), t.ClientCAFile)
+	if _, err := clientCAs.get(); err != nil {
+		return nil, err
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool, err := clientCAs.get()
+		if err != nil {
+			return nil, err
+		}
+		c := config.Clone()
+		c.ClientCAs = pool
+		c.GetConfigForClient = nil
+		return c, nil
+	}
+
+	return config, nil
+}
+
+// ClientTLS holds the CA of the server certificate and, for mutual TLS, the certificate of the client.
+// The client certificate is reloaded when its files change.
+type ClientTLS struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// RegisterFlags adds the flags of the settings to fs
+func (t *ClientTLS) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&t.Enabled, "tls", false, "connect with TLS, implied by the other -tls flags")
+	fs.StringVar(&t.CAFile, "tls-ca", "", "PEM CA certificates to verify the server with, default the system roots")
+	fs.StringVar(&t.CertFile, "tls-cert", "", "PEM client certificate for mutual TLS")
+	fs.StringVar(&t.KeyFile, "tls-key", "", "PEM private key of the client certificate")
+	fs.StringVar(&t.ServerName, "tls-server-name", "", "expected name in the server certificate, default the host of the address")
+}
+
+// Credentials returns the transport credentials of the client, insecure ones if TLS is disabled
+func (t *ClientTLS) Credentials() (credentials.TransportCredentials, error) {
+	if !t.Enabled && t.CAFile == "" && t.CertFile == "" && t.KeyFile == "" && t.ServerName == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: t.ServerName,
+	}
+
+	if t.CAFile != "" {
+		pool, err := certPoolLoader(t.CAFile)()
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, errors.New("-tls-cert and -tls-key must be set together")
+		}
+		certificate := newReloading(keyPairLoader(t.CertFile, t.KeyFile), t.CertFile, t.KeyFile)
+		if _, err := certificate.get(); err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certificate.get()
+		}
+	}
+
+	return credentials.NewTLS(config), nil
+}
+
+func keyPairLoader(certFile, keyFile string) func() (*tls.Certificate, error) {
+	return func() (*tls.Certificate, error) {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &certificate, nil
+	}
+}
+
+func certPoolLoader(file string) func() (*x509.CertPool, error) {
+	return func() (*x509.CertPool, error) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no PEM certificates", file)
+		}
+		return pool, nil
+	}
+}
+
+// reloading caches a value loaded from files and loads it again when one of them is modified.
+// If loading fails, e.g. because only the certificate of a key pair was replaced yet,
+// the previous value is kept and loading is retried on the next call.
+type reloading[T any] struct {
+	files []string
+	load  func() (T, error)
+
+	mu      sync.Mutex
+	value   T
+	loaded  bool
+	modTime time.Time
+}
+
+func newReloading[T any](load func() (T, error), files ...string) *reloading[T] {
+	return &reloading[T]{files: files, load: load}
+}
+
+func (r *reloading[T]) get() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTime, err := r.latestModTime()
+	if r.loaded && (err != nil || modTime.Equal(r.modTime)) {
+		return r.value, nil
+	}
+
+	value, err := r.load()
+	if err != nil {
+		if r.loaded {
+			return r.value, nil
+		}
+		return value, err
+	}
+
+	r.value, r.loaded, r.modTime = value, true, modTime
+	return value, nil
+}
+
+func (r *reloading[T]) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range r.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
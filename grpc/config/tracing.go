@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"os"
+	"strings"
+)
+
+// TraceExporters lists the values accepted by -trace-exporter
+var TraceExporters = []string{"none", "stdout", "file", "otlp"}
+
+// Tracing holds the settings of OpenTelemetry tracing.
+// The trace context is propagated in the traceparent and tracestate metadata (W3C Trace Context).
+type Tracing struct {
+	Exporter    string
+	File        string
+	Endpoint    string
+	SampleRatio float64
+}
+
+// RegisterFlags adds the flags of the settings to fs
+func (t *Tracing) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&t.Exporter, "trace-exporter", "none", "where to export traces to: "+strings.Join(TraceExporters, ", "))
+	fs.StringVar(&t.File, "trace-file", "traces.json", "file the spans are appended to as JSON lines (file exporter)")
+	fs.StringVar(&t.Endpoint, "trace-endpoint", "localhost:4317", "address of the OTLP/gRPC collector, without TLS (otlp exporter)")
+	fs.Float64Var(&t.SampleRatio, "trace-sample-ratio", 1, "fraction of new traces to sample, traces started by callers follow their decision")
+}
+
+// Start installs the global tracer provider and propagator for the given service.
+// The returned function flushes and stops the exporter, it must be called before exiting.
+func (t *Tracing) Start(ctx context.Context, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		return nil, errors.New("-trace-sample-ratio must be between 0 and 1")
+	}
+
+	var exporter sdktrace.SpanExporter
+	closeFile := func() error { return nil }
+	var err error
+	switch t.Exporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "file":
+		var file *os.File
+		file, err = os.OpenFile(t.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		closeFile = file.Close
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case "otlp":
+		exporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(t.Endpoint), otlptracegrpc.WithInsecure())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", t.Exporter)
+	}
+	if err != nil {
+		closeFile()
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		closeFile()
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeFile())
+	}, nil
+}
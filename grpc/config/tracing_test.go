@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTracingStartInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		tracing config.Tracing
+	}{
+		{"unknown exporter", config.Tracing{Exporter: "jaeger", SampleRatio: 1}},
+		{"negative sample ratio", config.Tracing{Exporter: "stdout", SampleRatio: -0.5}},
+		{"sample ratio above 1", config.Tracing{Exporter: "stdout", SampleRatio: 2}},
+		{"file in a missing directory", config.Tracing{Exporter: "file", File: filepath.Join(t.TempDir(), "missing", "traces.json"), SampleRatio: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.tracing.Start(context.Background(), "test"); err == nil {
+				t.Error("Start succeeded")
+			}
+		})
+	}
+}
+
+func TestTracingFileExporter(t *testing.T) {
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	file := filepath.Join(t.TempDir(), "traces.json")
+	tracing := config.Tracing{Exporter: "file", File: file, SampleRatio: 1}
+	stop, err := tracing.Start(context.Background(), "students-test")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "example")
+	span.End()
+	// Stopping flushes the spans
+	if err := stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"Name":"example"`, `"Value":"students-test"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("%s does not contain %s: %s", file, want, content)
+		}
+	}
+
+	// The trace context is propagated in the traceparent metadata
+	fields := otel.GetTextMapPropagator().Fields()
+	if !strings.Contains(strings.Join(fields, " "), "traceparent") {
+		t.Errorf("propagated fields = %v, want traceparent", fields)
+	}
+}
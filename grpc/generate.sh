@@ -2,6 +2,9 @@
 
 set -exuo pipefail
 
-protoc --go_out=. --go_opt=paths=source_relative \
+# third_party/googleapis contains google/api/annotations.proto and its dependencies
+protoc -I . -I third_party/googleapis \
+    --go_out=. --go_opt=paths=source_relative \
     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+    --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative \
     api/api.proto
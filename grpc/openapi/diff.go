@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// anyParameter replaces the names of path parameters, which may differ between the documents
+var anyParameter = regexp.MustCompile(`\{[^}]*\}`)
+
+// Diff compares the document generated from the proto definitions with a hand-written spec
+// and returns one line per difference. Paths of spec are relative to its first server URL.
+func Diff(generated, spec *openapi3.T) []string {
+	var drift []string
+	report := func(format string, args ...any) {
+		drift = append(drift, fmt.Sprintf(format, args...))
+	}
+
+	prefix := ""
+	if len(spec.Servers) > 0 {
+		if u, err := url.Parse(spec.Servers[0].URL); err == nil {
+			prefix = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	generatedOperations := operations(generated, "")
+	specOperations := operations(spec, prefix)
+
+	for _, key := range sortedKeys(specOperations) {
+		specOperation := specOperations[key]
+		generatedOperation, ok := generatedOperations[key]
+		if !ok {
+			report("%s: only in spec", specOperation)
+			continue
+		}
+		diffOperation(report, generatedOperation, specOperation)
+	}
+	for _, key := range sortedKeys(generatedOperations) {
+		if _, ok := specOperations[key]; !ok {
+			report("%s: only in proto", generatedOperations[key])
+		}
+	}
+
+	generatedSchemas := generated.Components.Schemas
+	specSchemas := openapi3.Schemas{}
+	if spec.Components != nil {
+		specSchemas = spec.Components.Schemas
+	}
+	for _, name := range sortedKeys(specSchemas) {
+		generatedSchema, ok := generatedSchemas[name]
+		if !ok {
+			report("schema %s: only in spec", name)
+			continue
+		}
+		diffProperties(report, "schema "+name, generatedSchema.Value, specSchemas[name].Value)
+	}
+	for _, name := range sortedKeys(generatedSchemas) {
+		if _, ok := specSchemas[name]; !ok {
+			report("schema %s: only in proto", name)
+		}
+	}
+
+	return drift
+}
+
+type operation struct {
+	method string
+	path   string
+	*openapi3.Operation
+}
+
+func (o operation) String() string {
+	return o.method + " " + o.path
+}
+
+// operations indexes the operations of doc by method and normalized path
+func operations(doc *openapi3.T, prefix string) map[string]operation {
+	result := make(map[string]operation)
+	for path, item := range doc.Paths {
+		for method, op := range item.Operations() {
+			key := method + " " + anyParameter.ReplaceAllString(prefix+path, "{}")
+			result[key] = operation{method: method, path: prefix + path, Operation: op}
+		}
+	}
+	return result
+}
+
+func diffOperation(report func(string, ...any), generated, spec operation) {
+	generatedParameters := parameters(generated.Operation)
+	specParameters := parameters(spec.Operation)
+	for _, key := range sortedKeys(specParameters) {
+		specParameter := specParameters[key]
+		generatedParameter, ok := generatedParameters[key]
+		if !ok {
+			report("%s: %s parameter %q only in spec", spec, specParameter.In, specParameter.Name)
+			continue
+		}
+		if g, s := describe(generatedParameter.Schema), describe(specParameter.Schema); g != s {
+			report("%s: %s parameter %q is %s in proto, %s in spec", spec, specParameter.In, specParameter.Name, g, s)
+		}
+	}
+	for _, key := range sortedKeys(generatedParameters) {
+		if _, ok := specParameters[key]; !ok && generatedParameters[key].In != openapi3.ParameterInPath {
+			parameter := generatedParameters[key]
+			report("%s: %s parameter %q only in proto", spec, parameter.In, parameter.Name)
+		}
+	}
+
+	switch {
+	case generated.RequestBody != nil && spec.RequestBody == nil:
+		report("%s: request body only in proto", spec)
+	case generated.RequestBody == nil && spec.RequestBody != nil:
+		report("%s: request body only in spec", spec)
+	case generated.RequestBody != nil:
+		diffContent(report, spec.String()+": request body", generated.RequestBody.Value.Content, spec.RequestBody.Value.Content)
+	}
+
+	generatedResponse, specResponse := generated.Responses.Get(200), spec.Responses.Get(200)
+	if generatedResponse != nil && specResponse != nil {
+		diffContent(report, spec.String()+": response", generatedResponse.Value.Content, specResponse.Value.Content)
+	}
+}
+
+// parameters indexes the parameters of op by location and name; path parameters by position
+func parameters(op *openapi3.Operation) map[string]*openapi3.Parameter {
+	result := make(map[string]*openapi3.Parameter)
+	position := 0
+	for _, ref := range op.Parameters {
+		parameter := ref.Value
+		key := parameter.In + " " + parameter.Name
+		if parameter.In == openapi3.ParameterInPath {
+			key = fmt.Sprintf("path %d", position)
+			position++
+		}
+		result[key] = parameter
+	}
+	return result
+}
+
+func diffContent(report func(string, ...any), context string, generated, spec openapi3.Content) {
+	for _, mediaType := range sortedKeys(spec) {
+		generatedMediaType, ok := generated[mediaType]
+		if !ok {
+			report("%s: media type %s only in spec", context, mediaType)
+			continue
+		}
+		if g, s := describe(generatedMediaType.Schema), describe(spec[mediaType].Schema); g != s {
+			report("%s: %s in proto, %s in spec", context, g, s)
+		}
+	}
+	for _, mediaType := range sortedKeys(generated) {
+		if _, ok := spec[mediaType]; !ok {
+			report("%s: media type %s only in proto", context, mediaType)
+		}
+	}
+}
+
+func diffProperties(report func(string, ...any), context string, generated, spec *openapi3.Schema) {
+	for _, name := range sortedKeys(spec.Properties) {
+		generatedProperty, ok := generated.Properties[name]
+		if !ok {
+			report("%s: property %q only in spec", context, name)
+			continue
+		}
+		if g, s := describe(generatedProperty), describe(spec.Properties[name]); g != s {
+			report("%s: property %q is %s in proto, %s in spec", context, name, g, s)
+		}
+	}
+	for _, name := range sortedKeys(generated.Properties) {
+		if _, ok := spec.Properties[name]; !ok {
+			report("%s: property %q only in proto", context, name)
+		}
+	}
+}
+
+// describe summarizes the shape of a schema, e.g. "array of Student";
+// formats and constraints are ignored
+func describe(ref *openapi3.SchemaRef) string {
+	switch {
+	case ref == nil:
+		return "untyped"
+	case ref.Ref != "":
+		return ref.Ref[strings.LastIndex(ref.Ref, "/")+1:]
+	case ref.Value == nil:
+		return "untyped"
+	case ref.Value.Type == openapi3.TypeArray:
+		return "array of " + describe(ref.Value.Items)
+	case ref.Value.Type == "":
+		return "untyped"
+	default:
+		return ref.Value.Type
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
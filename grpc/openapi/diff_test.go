@@ -0,0 +1,69 @@
+package openapi_test
+
+import (
+	"flag"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/openapi"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func generate(t *testing.T) *openapi3.T {
+	t.Helper()
+	generated, err := openapi.Generate("Students", "1.0.0", api.File_api_api_proto)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return generated
+}
+
+func load(t *testing.T, path string) *openapi3.T {
+	t.Helper()
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile(%s): %v", path, err)
+	}
+	if err := spec.Validate(loader.Context); err != nil {
+		t.Fatalf("%s is not a valid OpenAPI document: %v", path, err)
+	}
+	return spec
+}
+
+func TestDiff(t *testing.T) {
+	const golden = "testdata/drifted.golden"
+
+	drift := openapi.Diff(generate(t), load(t, "testdata/drifted.yaml"))
+	got := strings.Join(drift, "\n") + "\n"
+
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v, run go test -update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("Diff() = \n%s\nwant (%s)\n%s", got, golden, want)
+	}
+}
+
+func TestDiffGeneratedWithItself(t *testing.T) {
+	if drift := openapi.Diff(generate(t), generate(t)); len(drift) > 0 {
+		t.Errorf("Diff() of the same document = %v", drift)
+	}
+}
+
+// The committed document must match api.proto, see cmd/openapi
+func TestGatewayYAMLHasNoDrift(t *testing.T) {
+	const specPath = "../../swagger/gateway.yaml"
+	for _, line := range openapi.Diff(generate(t), load(t, specPath)) {
+		t.Errorf("%s: %s", specPath, line)
+	}
+}
@@ -0,0 +1,281 @@
+// Package openapi derives an OpenAPI 3 document from the google.api.http annotations
+// in api.proto and compares it with a hand-written document such as swagger/gateway.yaml.
+package openapi
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"regexp"
+	"strings"
+)
+
+// Media types of streamed request and response bodies
+const (
+	jsonMediaType   = "application/json"
+	ndjsonMediaType = "application/x-ndjson"
+)
+
+// pathParameter matches the variables of an HTTP rule path template, e.g. {student.id} or {name=shelves/*}
+var pathParameter = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+type generator struct {
+	doc *openapi3.T
+}
+
+// Generate returns an OpenAPI document with an operation for every method in files
+// that has a google.api.http option. Message fields use their proto names,
+// like the JSON/HTTP gateway of the server.
+func Generate(title, version string, files ...protoreflect.FileDescriptor) (*openapi3.T, error) {
+	g := &generator{doc: &openapi3.T{
+		OpenAPI:    "3.0.3",
+		Info:       &openapi3.Info{Title: title, Version: version},
+		Paths:      openapi3.Paths{},
+		Components: &openapi3.Components{Schemas: openapi3.Schemas{}},
+	}}
+	g.doc.Components.Schemas["Status"] = openapi3.NewSchemaRef("", statusSchema())
+
+	for _, file := range files {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				if err := g.addMethod(methods.Get(j)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return g.doc, nil
+}
+
+// statusSchema describes the JSON representation of google.rpc.Status returned for errors
+func statusSchema() *openapi3.Schema {
+	schema := openapi3.NewObjectSchema()
+	schema.Properties = openapi3.Schemas{
+		"code":    openapi3.NewSchemaRef("", openapi3.NewInt32Schema()),
+		"message": openapi3.NewSchemaRef("", openapi3.NewStringSchema()),
+		"details": openapi3.NewSchemaRef("", openapi3.NewArraySchema().WithItems(openapi3.NewObjectSchema())),
+	}
+	return schema
+}
+
+func (g *generator) addMethod(method protoreflect.MethodDescriptor) error {
+	rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil {
+		return nil
+	}
+
+	verb, template := httpPattern(rule)
+	if verb == "" {
+		return fmt.Errorf("%s: unsupported HTTP rule %v", method.FullName(), rule)
+	}
+
+	service := method.Parent().(protoreflect.ServiceDescriptor)
+	input := method.Input()
+	operation := &openapi3.Operation{
+		OperationID: fmt.Sprintf("%s_%s", service.Name(), method.Name()),
+		Tags:        []string{string(service.Name())},
+		Responses:   openapi3.Responses{},
+	}
+
+	// Path parameters
+	used := make(map[string]bool)
+	for _, match := range pathParameter.FindAllStringSubmatch(template, -1) {
+		name := match[1]
+		field := fieldByPath(input, name)
+		if field == nil {
+			return fmt.Errorf("%s: unknown path parameter %q", method.FullName(), name)
+		}
+		used[strings.SplitN(name, ".", 2)[0]] = true
+		parameter := openapi3.NewPathParameter(name).WithSchema(g.fieldSchema(field).Value)
+		operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{Value: parameter})
+	}
+
+	// Request body
+	mediaType := jsonMediaType
+	if method.IsStreamingClient() {
+		mediaType = ndjsonMediaType
+	}
+	switch rule.Body {
+	case "":
+	case "*":
+		body := openapi3.NewRequestBody().WithRequired(true).WithSchemaRef(g.messageSchema(input), []string{mediaType})
+		operation.RequestBody = &openapi3.RequestBodyRef{Value: body}
+	default:
+		field := input.Fields().ByName(protoreflect.Name(rule.Body))
+		if field == nil {
+			return fmt.Errorf("%s: unknown body field %q", method.FullName(), rule.Body)
+		}
+		used[rule.Body] = true
+		body := openapi3.NewRequestBody().WithRequired(true).WithSchemaRef(g.fieldSchema(field), []string{mediaType})
+		operation.RequestBody = &openapi3.RequestBodyRef{Value: body}
+	}
+
+	// All other fields are query parameters, unless the whole message is the body
+	if rule.Body != "*" {
+		fields := input.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if used[string(field.Name())] || !isQueryParameter(field) {
+				continue
+			}
+			parameter := openapi3.NewQueryParameter(string(field.Name())).WithSchema(g.fieldSchema(field).Value)
+			operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{Value: parameter})
+		}
+	}
+
+	// Responses
+	response := openapi3.NewResponse().WithDescription("OK")
+	if method.IsStreamingServer() {
+		// The gateway wraps every message of a stream
+		stream := openapi3.NewObjectSchema()
+		stream.Properties = openapi3.Schemas{
+			"result": g.messageSchema(method.Output()),
+			"error":  openapi3.NewSchemaRef("#/components/schemas/Status", nil),
+		}
+		response.WithContent(openapi3.Content{ndjsonMediaType: openapi3.NewMediaType().WithSchema(stream)})
+	} else {
+		response.WithJSONSchemaRef(g.messageSchema(method.Output()))
+	}
+	operation.Responses["200"] = &openapi3.ResponseRef{Value: response}
+
+	failure := openapi3.NewResponse().WithDescription("Error").
+		WithJSONSchemaRef(openapi3.NewSchemaRef("#/components/schemas/Status", nil))
+	operation.Responses["default"] = &openapi3.ResponseRef{Value: failure}
+
+	path := pathParameter.ReplaceAllString(template, "{$1}")
+	item := g.doc.Paths[path]
+	if item == nil {
+		item = &openapi3.PathItem{}
+		g.doc.Paths[path] = item
+	}
+	item.SetOperation(verb, operation)
+
+	return nil
+}
+
+// httpPattern returns the HTTP method and path template of rule
+func httpPattern(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return "GET", pattern.Get
+	case *annotations.HttpRule_Put:
+		return "PUT", pattern.Put
+	case *annotations.HttpRule_Post:
+		return "POST", pattern.Post
+	case *annotations.HttpRule_Delete:
+		return "DELETE", pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return "PATCH", pattern.Patch
+	default:
+		return "", ""
+	}
+}
+
+// fieldByPath resolves a dotted field path like "student.id" in message
+func fieldByPath(message protoreflect.MessageDescriptor, path string) protoreflect.FieldDescriptor {
+	var field protoreflect.FieldDescriptor
+	for _, name := range strings.Split(path, ".") {
+		if message == nil {
+			return nil
+		}
+		field = message.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return nil
+		}
+		message = field.Message()
+	}
+	return field
+}
+
+// isQueryParameter reports whether the gateway can populate field from the query string
+func isQueryParameter(field protoreflect.FieldDescriptor) bool {
+	if field.Kind() != protoreflect.MessageKind {
+		return !field.IsMap()
+	}
+	return field.Message().FullName() == "google.protobuf.FieldMask"
+}
+
+// messageSchema returns a reference to the component schema of message, adding it if necessary
+func (g *generator) messageSchema(message protoreflect.MessageDescriptor) *openapi3.SchemaRef {
+	switch message.FullName() {
+	case "google.protobuf.Empty":
+		return openapi3.NewSchemaRef("", openapi3.NewObjectSchema())
+	case "google.protobuf.FieldMask":
+		// Serialized as a comma-separated list of paths
+		return openapi3.NewSchemaRef("", openapi3.NewStringSchema())
+	}
+
+	name := string(message.Name())
+	ref := "#/components/schemas/" + name
+	if _, ok := g.doc.Components.Schemas[name]; ok {
+		return openapi3.NewSchemaRef(ref, nil)
+	}
+
+	schema := openapi3.NewObjectSchema()
+	// Register before the fields to support recursive messages
+	g.doc.Components.Schemas[name] = openapi3.NewSchemaRef("", schema)
+
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		schema.Properties[string(field.Name())] = g.fieldSchema(field)
+	}
+
+	return openapi3.NewSchemaRef(ref, nil)
+}
+
+func (g *generator) fieldSchema(field protoreflect.FieldDescriptor) *openapi3.SchemaRef {
+	if field.IsMap() {
+		schema := openapi3.NewObjectSchema()
+		additional := g.singularSchema(field.MapValue())
+		schema.AdditionalProperties = openapi3.AdditionalProperties{Schema: additional}
+		return openapi3.NewSchemaRef("", schema)
+	}
+
+	if field.IsList() {
+		schema := openapi3.NewArraySchema()
+		schema.Items = g.singularSchema(field)
+		return openapi3.NewSchemaRef("", schema)
+	}
+
+	return g.singularSchema(field)
+}
+
+// singularSchema follows the JSON mapping of protobuf, e.g. 64-bit integers are strings
+func (g *generator) singularSchema(field protoreflect.FieldDescriptor) *openapi3.SchemaRef {
+	var schema *openapi3.Schema
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageSchema(field.Message())
+	case protoreflect.BoolKind:
+		schema = openapi3.NewBoolSchema()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		schema = openapi3.NewInt32Schema()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		schema = openapi3.NewInt64Schema()
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		schema = openapi3.NewStringSchema().WithFormat("int64")
+	case protoreflect.FloatKind:
+		schema = openapi3.NewFloat64Schema().WithFormat("float")
+	case protoreflect.DoubleKind:
+		schema = openapi3.NewFloat64Schema().WithFormat("double")
+	case protoreflect.BytesKind:
+		schema = openapi3.NewBytesSchema()
+	case protoreflect.EnumKind:
+		schema = openapi3.NewStringSchema()
+		values := field.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+	default:
+		schema = openapi3.NewStringSchema()
+	}
+
+	return openapi3.NewSchemaRef("", schema)
+}
@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"io"
+	"net/http"
+)
+
+// DecodeNDJSON is an openapi3filter.BodyDecoder for streamed bodies of the gateway.
+// It validates every message against schema and returns the first one.
+func DecodeNDJSON(body io.Reader, _ http.Header, schema *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	decoder := json.NewDecoder(body)
+	var first any
+	for i := 1; ; i++ {
+		var message any
+		err := decoder.Decode(&message)
+		if err == io.EOF && i > 1 {
+			return first, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		if err := schema.Value.VisitJSON(message); err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		if i == 1 {
+			first = message
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/rest"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const specPath = "../../swagger/students.yaml"
+
+// contract sends requests to a REST server and validates both sides against the OpenAPI document
+type contract struct {
+	t      *testing.T
+	server *httptest.Server
+	router routers.Router
+}
+
+func newContract(t *testing.T, store storage.Store) *contract {
+	t.Helper()
+
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromFile(specPath)
+	if err != nil {
+		t.Fatalf("LoadFromFile(%s): %v", specPath, err)
+	}
+	if err := spec.Validate(loader.Context); err != nil {
+		t.Fatalf("%s is not a valid OpenAPI document: %v", specPath, err)
+	}
+
+	server := httptest.NewServer(rest.NewHandler(store))
+	t.Cleanup(server.Close)
+
+	// Route requests to the test server instead of the documented ones
+	spec.Servers = openapi3.Servers{{URL: server.URL}}
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	return &contract{t: t, server: server, router: router}
+}
+
+// get sends a GET request and checks the response against the document.
+// It reports whether the document accepts the request and returns the response.
+func (c *contract) get(path string) (bool, *http.Response, []byte) {
+	c.t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, c.server.URL+path, nil)
+	if err != nil {
+		c.t.Fatalf("NewRequest: %v", err)
+	}
+
+	route, pathParams, err := c.router.FindRoute(request)
+	if err != nil {
+		c.t.Fatalf("GET %s is not in %s: %v", path, specPath, err)
+	}
+	input := &openapi3filter.RequestValidationInput{
+		Request:    request,
+		PathParams: pathParams,
+		Route:      route,
+	}
+	requestErr := openapi3filter.ValidateRequest(context.Background(), input)
+
+	response, err := c.server.Client().Do(request)
+	if err != nil {
+		c.t.Fatalf("GET %s: %v", path, err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		c.t.Fatalf("GET %s: reading body: %v", path, err)
+	}
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 response.StatusCode,
+		Header:                 response.Header,
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+	if err != nil {
+		c.t.Errorf("GET %s: response does not match %s: %v", path, specPath, err)
+	}
+
+	return requestErr == nil, response, body
+}
+
+func seededStore(t *testing.T, n int) storage.Store {
+	t.Helper()
+	ctx := context.Background()
+
+	store := storage.NewMemory()
+	t.Cleanup(func() { store.Close() })
+
+	students := make([]*api.Student, n)
+	for i := range students {
+		students[i] = &api.Student{Name: "Student"}
+	}
+	if err := store.ImportStudents(ctx, students); err != nil {
+		t.Fatalf("ImportStudents: %v", err)
+	}
+
+	course := &api.Course{Name: "Cloud Computing", Description: "Distributed systems, containers and APIs", Capacity: 30}
+	if err := store.CreateCourse(ctx, course); err != nil {
+		t.Fatalf("CreateCourse: %v", err)
+	}
+	if err := store.Enroll(ctx, students[0].Id, course.Id); err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+
+	return store
+}
+
+func TestGetStudents(t *testing.T) {
+	c := newContract(t, seededStore(t, 5))
+
+	tests := []struct {
+		name  string
+		query string
+		count int
+	}{
+		{"minimum", "?limit=1", 1},
+		{"some", "?limit=3", 3},
+		{"all", "?limit=5", 5},
+		{"more than available", "?limit=100", 5},
+		{"more than the maximum", "?limit=1001", 5},
+		{"more than int32", "?limit=4294967296", 5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			valid, response, body := c.get("/students" + test.query)
+			if !valid {
+				t.Fatalf("%s rejects %s", specPath, test.query)
+			}
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", response.StatusCode, http.StatusOK, body)
+			}
+
+			var students []rest.Student
+			if err := json.Unmarshal(body, &students); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if len(students) != test.count {
+				t.Errorf("got %d students, want %d", len(students), test.count)
+			}
+		})
+	}
+}
+
+func TestGetStudentsMaximumLimit(t *testing.T) {
+	c := newContract(t, seededStore(t, rest.MaxLimit+1))
+
+	_, response, body := c.get("/students?limit=2000")
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", response.StatusCode, http.StatusOK, body)
+	}
+	var students []rest.Student
+	if err := json.Unmarshal(body, &students); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(students) != rest.MaxLimit {
+		t.Errorf("got %d students, want %d", len(students), rest.MaxLimit)
+	}
+}
+
+func TestGetStudentsCourses(t *testing.T) {
+	c := newContract(t, seededStore(t, 2))
+
+	_, response, body := c.get("/students?limit=2")
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", response.StatusCode, http.StatusOK, body)
+	}
+
+	// Decode generically to see the JSON as API consumers do
+	var students []map[string]any
+	if err := json.Unmarshal(body, &students); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	courses, ok := students[0]["courses"].([]any)
+	if !ok || len(courses) != 1 {
+		t.Fatalf("courses = %v, want one course", students[0]["courses"])
+	}
+	course := courses[0].(map[string]any)
+	for _, property := range []string{"id", "name", "description", "capacity"} {
+		if _, ok := course[property]; !ok {
+			t.Errorf("course has no %q property: %v", property, course)
+		}
+	}
+	if course["capacity"] != float64(30) {
+		t.Errorf("capacity = %v, want 30", course["capacity"])
+	}
+	if courses, ok := students[1]["courses"].([]any); !ok || len(courses) != 0 {
+		t.Errorf("courses = %v, want an empty array", students[1]["courses"])
+	}
+}
+
+func TestGetStudentsInvalidLimit(t *testing.T) {
+	c := newContract(t, seededStore(t, 5))
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing", ""},
+		{"empty", "?limit="},
+		{"zero", "?limit=0"},
+		{"negative", "?limit=-1"},
+		{"not a number", "?limit=abc"},
+		{"fraction", "?limit=1.5"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			valid, response, body := c.get("/students" + test.query)
+			if valid {
+				t.Errorf("%s accepts %q, but the server should reject it", specPath, test.query)
+			}
+			if response.StatusCode != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", response.StatusCode, http.StatusBadRequest, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Package rest implements the REST API described in swagger/students.yaml
+// on top of the same storage as the gRPC server.
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Student mirrors the Student schema of the OpenAPI document.
+type Student struct {
+	ID      int32    `json:"id"`
+	Name    string   `json:"name"`
+	Courses []Course `json:"courses"`
+}
+
+// Course mirrors the Course schema of the OpenAPI document.
+type Course struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Capacity    int32  `json:"capacity"`
+}
+
+// MaxLimit is the maximum number of students in a response, larger limits are reduced to it
+const MaxLimit = api.MaxPageSize
+
+type handler struct {
+	store storage.Store
+}
+
+// NewHandler returns a handler that serves the paths of the OpenAPI document.
+func NewHandler(store storage.Store) http.Handler {
+	h := &handler{store: store}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/students", h.getStudents)
+
+	return mux
+}
+
+func (h *handler) getStudents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	if !query.Has("limit") {
+		http.Error(w, "missing limit parameter", http.StatusBadRequest)
+		return
+	}
+	limit, err := strconv.ParseInt(query.Get("limit"), 10, 64)
+	if errors.Is(err, strconv.ErrRange) && limit > 0 {
+		err = nil
+	}
+	if err != nil || limit < 1 {
+		http.Error(w, "limit must be an integer greater than 0", http.StatusBadRequest)
+		return
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	students, err := h.store.ListStudents(r.Context(), 0, int(limit))
+	if err != nil {
+		log.Printf("Could not list students: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]Student, len(students))
+	for i, student := range students {
+		courses, err := h.store.ListStudentCourses(r.Context(), student.Id)
+		if err != nil {
+			log.Printf("Could not list courses of student %d: %v", student.Id, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		response[i] = newStudent(student, courses)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func newStudent(student *api.Student, courses []*api.Course) Student {
+	s := Student{ID: student.Id, Name: student.Name, Courses: make([]Course, len(courses))}
+	for i, course := range courses {
+		s.Courses[i] = Course{ID: course.Id, Name: course.Name, Description: course.Description, Capacity: course.Capacity}
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Could not write response: %v", err)
+	}
+}
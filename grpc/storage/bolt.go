@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+var (
+	studentsBucket = []byte("students")
+	coursesBucket  = []byte("courses")
+	// Enrollments are stored twice with empty values:
+	// keyed by course ID + student ID and by student ID + course ID
+	enrollmentsBucket    = []byte("enrollments")
+	studentCoursesBucket = []byte("student_courses")
+)
+
+// Bolt is a repository backed by an embedded bbolt key-value file.
+// Students and courses are stored as protobuf messages keyed by their big-endian ID,
+// so that the natural key order is the ID order.
+// IDs are allocated from the bucket's sequence, which is persisted with the data.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (or creates) the database at path.
+func OpenBolt(path string) (*Bolt, error) {
+	// Fail instead of blocking forever if another process holds the file lock
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{studentsBucket, coursesBucket, enrollmentsBucket, studentCoursesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+func boltKey(id int32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, uint32(id))
+	return key
+}
+
+// get reads the message with the given ID from bucket into m
+func (b *Bolt) get(bucket []byte, id int32, m proto.Message) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucket).Get(boltKey(id))
+		if value == nil {
+			return ErrNotFound
+		}
+		return proto.Unmarshal(value, m)
+	})
+}
+
+// list returns up to limit messages with an ID greater than afterID
+func list[M proto.Message](b *Bolt, bucket []byte, afterID int32, limit int, newMessage func() M) ([]M, error) {
+	var messages []M
+	err := b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucket).Cursor()
+		for key, value := cursor.Seek(boltKey(afterID + 1)); key != nil; key, value = cursor.Next() {
+			if limit > 0 && len(messages) == limit {
+				break
+			}
+
+			m := newMessage()
+			if err := proto.Unmarshal(value, m); err != nil {
+				return err
+			}
+			messages = append(messages, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// put stores m under the given ID
+func put(bucket *bolt.Bucket, id int32, m proto.Message) error {
+	value, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(boltKey(id), value)
+}
+
+// update reads the message with the given ID into m, calls update and stores m again
+func (b *Bolt) update(bucket []byte, id int32, m proto.Message, update func() error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucket)
+
+		value := bucket.Get(boltKey(id))
+		if value == nil {
+			return ErrNotFound
+		}
+		if err := proto.Unmarshal(value, m); err != nil {
+			return err
+		}
+
+		if err := update(); err != nil {
+			return err
+		}
+		return put(bucket, id, m)
+	})
+}
+
+// delete deletes the message with the given ID from bucket
+// and its enrollments from the index keyed by that ID and the reverse index
+func (b *Bolt) delete(bucket []byte, id int32, index, reverse []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucket)
+		if bucket.Get(boltKey(id)) == nil {
+			return ErrNotFound
+		}
+		if err := bucket.Delete(boltKey(id)); err != nil {
+			return err
+		}
+
+		// Collect keys first, deleting while iterating skips keys
+		var keys [][]byte
+		cursor := tx.Bucket(index).Cursor()
+		prefix := boltKey(id)
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			keys = append(keys, append([]byte(nil), key...))
+		}
+
+		for _, key := range keys {
+			if err := tx.Bucket(index).Delete(key); err != nil {
+				return err
+			}
+			if err := tx.Bucket(reverse).Delete(swapKey(key)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func enrollmentKey(first, second int32) []byte {
+	return append(boltKey(first), boltKey(second)...)
+}
+
+// swapKey turns a course + student key into a student + course key and vice versa
+func swapKey(key []byte) []byte {
+	return append(append([]byte(nil), key[4:]...), key[:4]...)
+}
+
+// nextID allocates an ID from the bucket's sequence
+func nextID(bucket *bolt.Bucket) (int32, error) {
+	id, err := bucket.NextSequence()
+	return int32(id), err
+}
+
+// GetStudent returns the student with the given ID or ErrNotFound.
+func (b *Bolt) GetStudent(_ context.Context, id int32) (*api.Student, error) {
+	student := &api.Student{}
+	if err := b.get(studentsBucket, id, student); err != nil {
+		return nil, err
+	}
+
+	return student, nil
+}
+
+// ListStudents returns up to limit students with an ID greater than afterID, ordered by ID.
+// A limit <= 0 returns all remaining students.
+func (b *Bolt) ListStudents(_ context.Context, afterID int32, limit int) ([]*api.Student, error) {
+	return list(b, studentsBucket, afterID, limit, func() *api.Student { return &api.Student{} })
+}
+
+// ImportStudents stores the given students in a single transaction.
+// Every student is assigned a new ID, which is written back to the passed message.
+func (b *Bolt) ImportStudents(_ context.Context, students []*api.Student) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(studentsBucket)
+
+		for _, student := range students {
+			id, err := nextID(bucket)
+			if err != nil {
+				return err
+			}
+			student.Id = id
+
+			if err := put(bucket, id, student); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// UpdateStudent atomically applies update to the student with the given ID.
+func (b *Bolt) UpdateStudent(_ context.Context, id int32, update func(student *api.Student) error) (*api.Student, error) {
+	student := &api.Student{}
+	err := b.update(studentsBucket, id, student, func() error {
+		if err := update(student); err != nil {
+			return err
+		}
+		student.Id = id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return student, nil
+}
+
+// DeleteStudent deletes the student with the given ID or returns ErrNotFound.
+func (b *Bolt) DeleteStudent(_ context.Context, id int32) error {
+	return b.delete(studentsBucket, id, studentCoursesBucket, enrollmentsBucket)
+}
+
+func (b *Bolt) GetCourse(_ context.Context, id int32) (*api.Course, error) {
+	course := &api.Course{}
+	if err := b.get(coursesBucket, id, course); err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+func (b *Bolt) ListCourses(_ context.Context, afterID int32, limit int) ([]*api.Course, error) {
+	return list(b, coursesBucket, afterID, limit, func() *api.Course { return &api.Course{} })
+}
+
+func (b *Bolt) CreateCourse(_ context.Context, course *api.Course) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(coursesBucket)
+
+		id, err := nextID(bucket)
+		if err != nil {
+			return err
+		}
+		course.Id = id
+
+		return put(bucket, id, course)
+	})
+}
+
+func (b *Bolt) UpdateCourse(_ context.Context, id int32, update func(course *api.Course) error) (*api.Course, error) {
+	course := &api.Course{}
+	err := b.update(coursesBucket, id, course, func() error {
+		if err := update(course); err != nil {
+			return err
+		}
+		course.Id = id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+func (b *Bolt) DeleteCourse(_ context.Context, id int32) error {
+	return b.delete(coursesBucket, id, enrollmentsBucket, studentCoursesBucket)
+}
+
+func (b *Bolt) Enroll(_ context.Context, studentID, courseID int32) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(studentsBucket).Get(boltKey(studentID)) == nil {
+			return ErrNotFound
+		}
+
+		value := tx.Bucket(coursesBucket).Get(boltKey(courseID))
+		if value == nil {
+			return ErrNotFound
+		}
+		course := &api.Course{}
+		if err := proto.Unmarshal(value, course); err != nil {
+			return err
+		}
+
+		enrollments := tx.Bucket(enrollmentsBucket)
+		if enrollments.Get(enrollmentKey(courseID, studentID)) != nil {
+			return ErrAlreadyExists
+		}
+
+		if course.Capacity > 0 {
+			enrolled := 0
+			cursor := enrollments.Cursor()
+			prefix := boltKey(courseID)
+			for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+				enrolled++
+			}
+			if enrolled >= int(course.Capacity) {
+				return ErrCourseFull
+			}
+		}
+
+		if err := enrollments.Put(enrollmentKey(courseID, studentID), []byte{}); err != nil {
+			return err
+		}
+		return tx.Bucket(studentCoursesBucket).Put(enrollmentKey(studentID, courseID), []byte{})
+	})
+}
+
+func (b *Bolt) Unenroll(_ context.Context, studentID, courseID int32) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		enrollments := tx.Bucket(enrollmentsBucket)
+		if enrollments.Get(enrollmentKey(courseID, studentID)) == nil {
+			return ErrNotFound
+		}
+
+		if err := enrollments.Delete(enrollmentKey(courseID, studentID)); err != nil {
+			return err
+		}
+		return tx.Bucket(studentCoursesBucket).Delete(enrollmentKey(studentID, courseID))
+	})
+}
+
+// related returns up to limit messages from bucket whose IDs follow id in the index, starting after afterID.
+// It returns ErrNotFound if id does not exist in owner.
+func related[M proto.Message](b *Bolt, owner, index, bucket []byte, id, afterID int32, limit int, newMessage func() M) ([]M, error) {
+	var messages []M
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(owner).Get(boltKey(id)) == nil {
+			return ErrNotFound
+		}
+
+		cursor := tx.Bucket(index).Cursor()
+		prefix := boltKey(id)
+		for key, _ := cursor.Seek(enrollmentKey(id, afterID+1)); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			if limit > 0 && len(messages) == limit {
+				break
+			}
+
+			m := newMessage()
+			if err := proto.Unmarshal(tx.Bucket(bucket).Get(key[4:]), m); err != nil {
+				return err
+			}
+			messages = append(messages, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (b *Bolt) ListStudentCourses(_ context.Context, studentID int32) ([]*api.Course, error) {
+	return related(b, studentsBucket, studentCoursesBucket, coursesBucket, studentID, 0, 0, func() *api.Course { return &api.Course{} })
+}
+
+func (b *Bolt) ListCourseStudents(_ context.Context, courseID, afterID int32, limit int) ([]*api.Student, error) {
+	return related(b, coursesBucket, enrollmentsBucket, studentsBucket, courseID, afterID, limit, func() *api.Student { return &api.Student{} })
+}
@@ -0,0 +1,17 @@
+package storage_test
+
+import (
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage/storagetest"
+	"testing"
+)
+
+func TestBolt(t *testing.T) {
+	storagetest.RunPersistent(t, func(t *testing.T, path string) storage.Store {
+		store, err := storage.OpenBolt(path)
+		if err != nil {
+			t.Fatalf("OpenBolt() = %v", err)
+		}
+		return store
+	})
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"google.golang.org/protobuf/proto"
+	"sort"
+	"sync"
+)
+
+// Memory is a thread-safe in-memory repository.
+// Data is lost when the process exits.
+type Memory struct {
+	mu       sync.RWMutex
+	students map[int32]*api.Student
+	lastID   int32
+
+	courses      map[int32]*api.Course
+	lastCourseID int32
+
+	// Course ID -> set of student IDs
+	enrollments map[int32]map[int32]bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		students:    make(map[int32]*api.Student),
+		courses:     make(map[int32]*api.Course),
+		enrollments: make(map[int32]map[int32]bool),
+	}
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// GetStudent returns the student with the given ID or ErrNotFound.
+func (m *Memory) GetStudent(_ context.Context, id int32) (*api.Student, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	student, ok := m.students[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return proto.Clone(student).(*api.Student), nil
+}
+
+// ListStudents returns up to limit students with an ID greater than afterID, ordered by ID.
+// A limit <= 0 returns all remaining students.
+func (m *Memory) ListStudents(_ context.Context, afterID int32, limit int) ([]*api.Student, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := pageIDs(m.students, afterID, limit)
+	students := make([]*api.Student, len(ids))
+	for i, id := range ids {
+		students[i] = proto.Clone(m.students[id]).(*api.Student)
+	}
+
+	return students, nil
+}
+
+// ImportStudents stores the given students.
+// Every student is assigned a new ID, which is written back to the passed message.
+func (m *Memory) ImportStudents(_ context.Context, students []*api.Student) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, student := range students {
+		m.lastID++
+		student.Id = m.lastID
+		m.students[student.Id] = proto.Clone(student).(*api.Student)
+	}
+
+	return nil
+}
+
+// UpdateStudent atomically applies update to the student with the given ID.
+func (m *Memory) UpdateStudent(_ context.Context, id int32, update func(student *api.Student) error) (*api.Student, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.students[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	student := proto.Clone(existing).(*api.Student)
+	if err := update(student); err != nil {
+		return nil, err
+	}
+	student.Id = id
+	m.students[id] = student
+
+	return proto.Clone(student).(*api.Student), nil
+}
+
+// DeleteStudent deletes the student with the given ID or returns ErrNotFound.
+func (m *Memory) DeleteStudent(_ context.Context, id int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.students[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.students, id)
+	for _, students := range m.enrollments {
+		delete(students, id)
+	}
+
+	return nil
+}
+
+// pageIDs returns up to limit keys of rows greater than afterID in ascending order
+func pageIDs[V any](rows map[int32]V, afterID int32, limit int) []int32 {
+	ids := make([]int32, 0, len(rows))
+	for id := range rows {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	return ids
+}
+
+func (m *Memory) GetCourse(_ context.Context, id int32) (*api.Course, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	course, ok := m.courses[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return proto.Clone(course).(*api.Course), nil
+}
+
+func (m *Memory) ListCourses(_ context.Context, afterID int32, limit int) ([]*api.Course, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := pageIDs(m.courses, afterID, limit)
+	courses := make([]*api.Course, len(ids))
+	for i, id := range ids {
+		courses[i] = proto.Clone(m.courses[id]).(*api.Course)
+	}
+
+	return courses, nil
+}
+
+func (m *Memory) CreateCourse(_ context.Context, course *api.Course) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastCourseID++
+	course.Id = m.lastCourseID
+	m.courses[course.Id] = proto.Clone(course).(*api.Course)
+
+	return nil
+}
+
+func (m *Memory) UpdateCourse(_ context.Context, id int32, update func(course *api.Course) error) (*api.Course, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.courses[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	course := proto.Clone(existing).(*api.Course)
+	if err := update(course); err != nil {
+		return nil, err
+	}
+	course.Id = id
+	m.courses[id] = course
+
+	return proto.Clone(course).(*api.Course), nil
+}
+
+func (m *Memory) DeleteCourse(_ context.Context, id int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.courses[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.courses, id)
+	delete(m.enrollments, id)
+
+	return nil
+}
+
+func (m *Memory) Enroll(_ context.Context, studentID, courseID int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	course, ok := m.courses[courseID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.students[studentID]; !ok {
+		return ErrNotFound
+	}
+
+	students := m.enrollments[courseID]
+	if students[studentID] {
+		return ErrAlreadyExists
+	}
+	if course.Capacity > 0 && len(students) >= int(course.Capacity) {
+		return ErrCourseFull
+	}
+
+	if students == nil {
+		students = make(map[int32]bool)
+		m.enrollments[courseID] = students
+	}
+	students[studentID] = true
+
+	return nil
+}
+
+func (m *Memory) Unenroll(_ context.Context, studentID, courseID int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.enrollments[courseID][studentID] {
+		return ErrNotFound
+	}
+	delete(m.enrollments[courseID], studentID)
+
+	return nil
+}
+
+func (m *Memory) ListStudentCourses(_ context.Context, studentID int32) ([]*api.Course, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.students[studentID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	var courses []*api.Course
+	for _, id := range pageIDs(m.courses, 0, 0) {
+		if m.enrollments[id][studentID] {
+			courses = append(courses, proto.Clone(m.courses[id]).(*api.Course))
+		}
+	}
+
+	return courses, nil
+}
+
+func (m *Memory) ListCourseStudents(_ context.Context, courseID, afterID int32, limit int) ([]*api.Student, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.courses[courseID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	ids := pageIDs(m.enrollments[courseID], afterID, limit)
+	students := make([]*api.Student, len(ids))
+	for i, id := range ids {
+		students[i] = proto.Clone(m.students[id]).(*api.Student)
+	}
+
+	return students, nil
+}
@@ -0,0 +1,13 @@
+package storage_test
+
+import (
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage/storagetest"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		return storage.NewMemory()
+	})
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+)
+
+// Observer is called before every operation of an observed Store, e.g. to record metrics or traces.
+// operation is the name of the Store method. The returned context is passed to the backend
+// and the returned function is called with the result once the operation is finished.
+type Observer func(ctx context.Context, operation string) (context.Context, func(err error))
+
+// Observe returns a Store that calls observer for every operation of store except Close.
+func Observe(store Store, observer Observer) Store {
+	return &observed{store: store, observer: observer}
+}
+
+type observed struct {
+	store    Store
+	observer Observer
+}
+
+func (o *observed) Close() error {
+	return o.store.Close()
+}
+
+func (o *observed) GetStudent(ctx context.Context, id int32) (student *api.Student, err error) {
+	ctx, done := o.observer(ctx, "GetStudent")
+	defer func() { done(err) }()
+	return o.store.GetStudent(ctx, id)
+}
+
+func (o *observed) ListStudents(ctx context.Context, afterID int32, limit int) (students []*api.Student, err error) {
+	ctx, done := o.observer(ctx, "ListStudents")
+	defer func() { done(err) }()
+	return o.store.ListStudents(ctx, afterID, limit)
+}
+
+func (o *observed) ImportStudents(ctx context.Context, students []*api.Student) (err error) {
+	ctx, done := o.observer(ctx, "ImportStudents")
+	defer func() { done(err) }()
+	return o.store.ImportStudents(ctx, students)
+}
+
+func (o *observed) UpdateStudent(ctx context.Context, id int32, update func(student *api.Student) error) (student *api.Student, err error) {
+	ctx, done := o.observer(ctx, "UpdateStudent")
+	defer func() { done(err) }()
+	return o.store.UpdateStudent(ctx, id, update)
+}
+
+func (o *observed) DeleteStudent(ctx context.Context, id int32) (err error) {
+	ctx, done := o.observer(ctx, "DeleteStudent")
+	defer func() { done(err) }()
+	return o.store.DeleteStudent(ctx, id)
+}
+
+func (o *observed) GetCourse(ctx context.Context, id int32) (course *api.Course, err error) {
+	ctx, done := o.observer(ctx, "GetCourse")
+	defer func() { done(err) }()
+	return o.store.GetCourse(ctx, id)
+}
+
+func (o *observed) ListCourses(ctx context.Context, afterID int32, limit int) (courses []*api.Course, err error) {
+	ctx, done := o.observer(ctx, "ListCourses")
+	defer func() { done(err) }()
+	return o.store.ListCourses(ctx, afterID, limit)
+}
+
+func (o *observed) CreateCourse(ctx context.Context, course *api.Course) (err error) {
+	ctx, done := o.observer(ctx, "CreateCourse")
+	defer func() { done(err) }()
+	return o.store.CreateCourse(ctx, course)
+}
+
+func (o *observed) UpdateCourse(ctx context.Context, id int32, update func(course *api.Course) error) (course *api.Course, err error) {
+	ctx, done := o.observer(ctx, "UpdateCourse")
+	defer func() { done(err) }()
+	return o.store.UpdateCourse(ctx, id, update)
+}
+
+func (o *observed) DeleteCourse(ctx context.Context, id int32) (err error) {
+	ctx, done := o.observer(ctx, "DeleteCourse")
+	defer func() { done(err) }()
+	return o.store.DeleteCourse(ctx, id)
+}
+
+func (o *observed) Enroll(ctx context.Context, studentID, courseID int32) (err error) {
+	ctx, done := o.observer(ctx, "Enroll")
+	defer func() { done(err) }()
+	return o.store.Enroll(ctx, studentID, courseID)
+}
+
+func (o *observed) Unenroll(ctx context.Context, studentID, courseID int32) (err error) {
+	ctx, done := o.observer(ctx, "Unenroll")
+	defer func() { done(err) }()
+	return o.store.Unenroll(ctx, studentID, courseID)
+}
+
+func (o *observed) ListStudentCourses(ctx context.Context, studentID int32) (courses []*api.Course, err error) {
+	ctx, done := o.observer(ctx, "ListStudentCourses")
+	defer func() { done(err) }()
+	return o.store.ListStudentCourses(ctx, studentID)
+}
+
+func (o *observed) ListCourseStudents(ctx context.Context, courseID, afterID int32, limit int) (students []*api.Student, err error) {
+	ctx, done := o.observer(ctx, "ListCourseStudents")
+	defer func() { done(err) }()
+	return o.store.ListCourseStudents(ctx, courseID, afterID, limit)
+}
@@ -0,0 +1,16 @@
+package storage_test
+
+import (
+	"context"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage/storagetest"
+	"testing"
+)
+
+func TestObserve(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		return storage.Observe(storage.NewMemory(), func(ctx context.Context, _ string) (context.Context, func(error)) {
+			return ctx, func(error) {}
+		})
+	})
+}
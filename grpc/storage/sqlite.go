@@ -0,0 +1,467 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order. The index of the last applied migration
+// is tracked in SQLite's user_version pragma.
+// Never change an existing migration, append a new one instead.
+var migrations = []string{
+	`CREATE TABLE students (
+		id   INTEGER PRIMARY KEY,
+		name TEXT NOT NULL
+	)`,
+	// Persist the highest allocated ID, so that IDs are never reused
+	`CREATE TABLE sequences (
+		name  TEXT PRIMARY KEY,
+		value INTEGER NOT NULL
+	);
+	INSERT INTO sequences (name, value) SELECT 'students', COALESCE(MAX(id), 0) FROM students`,
+	`CREATE TABLE courses (
+		id          INTEGER PRIMARY KEY,
+		name        TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT ''
+	);
+	INSERT INTO sequences (name, value) VALUES ('courses', 0)`,
+	`ALTER TABLE courses ADD COLUMN capacity INTEGER NOT NULL DEFAULT 0;
+	CREATE TABLE enrollments (
+		course_id  INTEGER NOT NULL,
+		student_id INTEGER NOT NULL,
+		PRIMARY KEY (course_id, student_id)
+	);
+	CREATE INDEX enrollments_student_id ON enrollments (student_id)`,
+}
+
+// SQLite is a repository that persists students in a SQLite database file.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (or creates) the database at path and applies pending migrations.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only supports a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+
+		// PRAGMA does not support placeholders
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reserveIDs allocates n consecutive IDs from the named sequence and returns the first one
+func reserveIDs(ctx context.Context, tx *sql.Tx, sequence string, n int) (int32, error) {
+	var last int32
+	err := tx.QueryRowContext(ctx, "UPDATE sequences SET value = value + ? WHERE name = ? RETURNING value", n, sequence).Scan(&last)
+	if err != nil {
+		return 0, err
+	}
+
+	return last - int32(n) + 1, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// GetStudent returns the student with the given ID or ErrNotFound.
+func (s *SQLite) GetStudent(ctx context.Context, id int32) (*api.Student, error) {
+	student := &api.Student{}
+	err := s.db.QueryRowContext(ctx, "SELECT id, name FROM students WHERE id = ?", id).Scan(&student.Id, &student.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return student, nil
+}
+
+// ListStudents returns up to limit students with an ID greater than afterID, ordered by ID.
+// A limit <= 0 returns all remaining students.
+func (s *SQLite) ListStudents(ctx context.Context, afterID int32, limit int) ([]*api.Student, error) {
+	// A negative LIMIT means no limit in SQLite
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name FROM students WHERE id > ? ORDER BY id LIMIT ?", afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []*api.Student
+	for rows.Next() {
+		student := &api.Student{}
+		if err := rows.Scan(&student.Id, &student.Name); err != nil {
+			return nil, err
+		}
+		students = append(students, student)
+	}
+
+	return students, rows.Err()
+}
+
+// ImportStudents stores the given students in a single transaction.
+// Every student is assigned a new ID, which is written back to the passed message.
+func (s *SQLite) ImportStudents(ctx context.Context, students []*api.Student) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	next, err := reserveIDs(ctx, tx, "students", len(students))
+	if err != nil {
+		return err
+	}
+
+	statement, err := tx.PrepareContext(ctx, "INSERT INTO students (id, name) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	for _, student := range students {
+		student.Id = next
+		next++
+
+		if _, err := statement.ExecContext(ctx, student.Id, student.Name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStudent atomically applies update to the student with the given ID.
+func (s *SQLite) UpdateStudent(ctx context.Context, id int32, update func(student *api.Student) error) (*api.Student, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	student := &api.Student{}
+	err = tx.QueryRowContext(ctx, "SELECT id, name FROM students WHERE id = ?", id).Scan(&student.Id, &student.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update(student); err != nil {
+		return nil, err
+	}
+	student.Id = id
+
+	if _, err := tx.ExecContext(ctx, "UPDATE students SET name = ? WHERE id = ?", student.Name, id); err != nil {
+		return nil, err
+	}
+
+	return student, tx.Commit()
+}
+
+// DeleteStudent deletes the student with the given ID or returns ErrNotFound.
+func (s *SQLite) DeleteStudent(ctx context.Context, id int32) error {
+	return s.deleteWithEnrollments(ctx, "students", "student_id", id)
+}
+
+// deleteWithEnrollments deletes a student or course and its enrollments in a single transaction
+func (s *SQLite) deleteWithEnrollments(ctx context.Context, table, column string, id int32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Table and column names are constants, never user input
+	result, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM enrollments WHERE "+column+" = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLite) GetCourse(ctx context.Context, id int32) (*api.Course, error) {
+	course := &api.Course{}
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, description, capacity FROM courses WHERE id = ?", id).Scan(&course.Id, &course.Name, &course.Description, &course.Capacity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+func (s *SQLite) ListCourses(ctx context.Context, afterID int32, limit int) ([]*api.Course, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, description, capacity FROM courses WHERE id > ? ORDER BY id LIMIT ?", afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*api.Course
+	for rows.Next() {
+		course := &api.Course{}
+		if err := rows.Scan(&course.Id, &course.Name, &course.Description, &course.Capacity); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, rows.Err()
+}
+
+func (s *SQLite) CreateCourse(ctx context.Context, course *api.Course) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := reserveIDs(ctx, tx, "courses", 1)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO courses (id, name, description, capacity) VALUES (?, ?, ?, ?)", id, course.Name, course.Description, course.Capacity)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	course.Id = id
+
+	return nil
+}
+
+func (s *SQLite) UpdateCourse(ctx context.Context, id int32, update func(course *api.Course) error) (*api.Course, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	course := &api.Course{}
+	err = tx.QueryRowContext(ctx, "SELECT id, name, description, capacity FROM courses WHERE id = ?", id).Scan(&course.Id, &course.Name, &course.Description, &course.Capacity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update(course); err != nil {
+		return nil, err
+	}
+	course.Id = id
+
+	_, err = tx.ExecContext(ctx, "UPDATE courses SET name = ?, description = ?, capacity = ? WHERE id = ?", course.Name, course.Description, course.Capacity, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return course, tx.Commit()
+}
+
+func (s *SQLite) DeleteCourse(ctx context.Context, id int32) error {
+	return s.deleteWithEnrollments(ctx, "courses", "course_id", id)
+}
+
+// exists reports whether a row with the given ID exists in table
+func exists(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, table string, id int32) (bool, error) {
+	var found bool
+	err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = ?)", id).Scan(&found)
+	return found, err
+}
+
+func (s *SQLite) Enroll(ctx context.Context, studentID, courseID int32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	found, err := exists(ctx, tx, "students", studentID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+
+	var capacity, enrolled int
+	var enrolledAlready bool
+	err = tx.QueryRowContext(ctx, `SELECT capacity,
+		(SELECT COUNT(*) FROM enrollments WHERE course_id = courses.id),
+		EXISTS (SELECT 1 FROM enrollments WHERE course_id = courses.id AND student_id = ?)
+		FROM courses WHERE id = ?`, studentID, courseID).Scan(&capacity, &enrolled, &enrolledAlready)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if enrolledAlready {
+		return ErrAlreadyExists
+	}
+	if capacity > 0 && enrolled >= capacity {
+		return ErrCourseFull
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO enrollments (course_id, student_id) VALUES (?, ?)", courseID, studentID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLite) Unenroll(ctx context.Context, studentID, courseID int32) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM enrollments WHERE course_id = ? AND student_id = ?", courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLite) ListStudentCourses(ctx context.Context, studentID int32) ([]*api.Course, error) {
+	found, err := exists(ctx, s.db, "students", studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT courses.id, courses.name, courses.description, courses.capacity
+		FROM courses JOIN enrollments ON enrollments.course_id = courses.id
+		WHERE enrollments.student_id = ? ORDER BY courses.id`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*api.Course
+	for rows.Next() {
+		course := &api.Course{}
+		if err := rows.Scan(&course.Id, &course.Name, &course.Description, &course.Capacity); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, rows.Err()
+}
+
+func (s *SQLite) ListCourseStudents(ctx context.Context, courseID, afterID int32, limit int) ([]*api.Student, error) {
+	found, err := exists(ctx, s.db, "courses", courseID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT students.id, students.name
+		FROM students JOIN enrollments ON enrollments.student_id = students.id
+		WHERE enrollments.course_id = ? AND students.id > ? ORDER BY students.id LIMIT ?`, courseID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []*api.Student
+	for rows.Next() {
+		student := &api.Student{}
+		if err := rows.Scan(&student.Id, &student.Name); err != nil {
+			return nil, err
+		}
+		students = append(students, student)
+	}
+
+	return students, rows.Err()
+}
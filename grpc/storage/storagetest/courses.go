@@ -0,0 +1,119 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"testing"
+)
+
+func mustCreateCourse(t *testing.T, store storage.Store, name, description string) *api.Course {
+	t.Helper()
+	course := &api.Course{Name: name, Description: description}
+	if err := store.CreateCourse(context.Background(), course); err != nil {
+		t.Fatalf("CreateCourse(%q) = %v", name, err)
+	}
+	return course
+}
+
+func testCreateCourse(t *testing.T, store storage.Store) {
+	// Course IDs are independent of student IDs
+	mustImport(t, store, students("Ada Lovelace", "Alan Turing"))
+
+	course := mustCreateCourse(t, store, "Cloud Computing", "gRPC and OpenAPI")
+	if course.Id <= 0 {
+		t.Fatalf("CreateCourse() assigned ID %d, want > 0", course.Id)
+	}
+
+	got, err := store.GetCourse(context.Background(), course.Id)
+	if err != nil {
+		t.Fatalf("GetCourse(%d) = %v", course.Id, err)
+	}
+	if got.Name != "Cloud Computing" || got.Description != "gRPC and OpenAPI" {
+		t.Errorf("GetCourse(%d) = %v, want %v", course.Id, got, course)
+	}
+
+	if _, err := store.GetCourse(context.Background(), course.Id+1); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetCourse(%d) = %v, want ErrNotFound", course.Id+1, err)
+	}
+
+	next := mustCreateCourse(t, store, "Databases", "")
+	if next.Id <= course.Id {
+		t.Errorf("CreateCourse() assigned ID %d after %d", next.Id, course.Id)
+	}
+}
+
+func testListCourses(t *testing.T, store storage.Store) {
+	var created []int32
+	for _, name := range []string{"A", "B", "C"} {
+		created = append(created, mustCreateCourse(t, store, name, "").Id)
+	}
+
+	all, err := store.ListCourses(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatalf("ListCourses(0, 0) = %v", err)
+	}
+	var got []int32
+	for _, course := range all {
+		got = append(got, course.Id)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(created) {
+		t.Fatalf("ListCourses(0, 0) IDs = %v, want %v", got, created)
+	}
+
+	page, err := store.ListCourses(context.Background(), created[0], 1)
+	if err != nil {
+		t.Fatalf("ListCourses(%d, 1) = %v", created[0], err)
+	}
+	if len(page) != 1 || page[0].Id != created[1] {
+		t.Errorf("ListCourses(%d, 1) = %v, want course %d", created[0], page, created[1])
+	}
+}
+
+func testUpdateCourse(t *testing.T, store storage.Store) {
+	course := mustCreateCourse(t, store, "Cloud Computing", "")
+
+	updated, err := store.UpdateCourse(context.Background(), course.Id, func(course *api.Course) error {
+		course.Description = "gRPC and OpenAPI"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateCourse(%d) = %v", course.Id, err)
+	}
+	if updated.Name != "Cloud Computing" || updated.Description != "gRPC and OpenAPI" {
+		t.Errorf("UpdateCourse(%d) = %v", course.Id, updated)
+	}
+
+	got, err := store.GetCourse(context.Background(), course.Id)
+	if err != nil {
+		t.Fatalf("GetCourse(%d) = %v", course.Id, err)
+	}
+	if got.Description != "gRPC and OpenAPI" {
+		t.Errorf("GetCourse(%d).Description = %q, want %q", course.Id, got.Description, "gRPC and OpenAPI")
+	}
+
+	_, err = store.UpdateCourse(context.Background(), course.Id+1, func(*api.Course) error { return nil })
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateCourse(%d) = %v, want ErrNotFound", course.Id+1, err)
+	}
+}
+
+func testDeleteCourse(t *testing.T, store storage.Store) {
+	course := mustCreateCourse(t, store, "Cloud Computing", "")
+
+	if err := store.DeleteCourse(context.Background(), course.Id); err != nil {
+		t.Fatalf("DeleteCourse(%d) = %v", course.Id, err)
+	}
+	if _, err := store.GetCourse(context.Background(), course.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetCourse(%d) after delete = %v, want ErrNotFound", course.Id, err)
+	}
+	if err := store.DeleteCourse(context.Background(), course.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("second DeleteCourse(%d) = %v, want ErrNotFound", course.Id, err)
+	}
+
+	if next := mustCreateCourse(t, store, "Databases", ""); next.Id <= course.Id {
+		t.Errorf("CreateCourse() reused ID %d after deleting %d", next.Id, course.Id)
+	}
+}
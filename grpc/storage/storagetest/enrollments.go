@@ -0,0 +1,170 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"testing"
+)
+
+func mustEnroll(t *testing.T, store storage.Store, studentID, courseID int32) {
+	t.Helper()
+	if err := store.Enroll(context.Background(), studentID, courseID); err != nil {
+		t.Fatalf("Enroll(%d, %d) = %v", studentID, courseID, err)
+	}
+}
+
+func courseIDs(courses []*api.Course) []int32 {
+	ids := make([]int32, len(courses))
+	for i, course := range courses {
+		ids[i] = course.Id
+	}
+	return ids
+}
+
+func studentCourses(t *testing.T, store storage.Store, studentID int32) []int32 {
+	t.Helper()
+	courses, err := store.ListStudentCourses(context.Background(), studentID)
+	if err != nil {
+		t.Fatalf("ListStudentCourses(%d) = %v", studentID, err)
+	}
+	return courseIDs(courses)
+}
+
+func courseStudents(t *testing.T, store storage.Store, courseID, afterID int32, limit int) []int32 {
+	t.Helper()
+	students, err := store.ListCourseStudents(context.Background(), courseID, afterID, limit)
+	if err != nil {
+		t.Fatalf("ListCourseStudents(%d, %d, %d) = %v", courseID, afterID, limit, err)
+	}
+	return ids(students)
+}
+
+func testEnroll(t *testing.T, store storage.Store) {
+	enrolled := students("Ada Lovelace", "Alan Turing", "Grace Hopper")
+	mustImport(t, store, enrolled)
+	cloud := mustCreateCourse(t, store, "Cloud Computing", "")
+	databases := mustCreateCourse(t, store, "Databases", "")
+	mustCreateCourse(t, store, "Software Engineering", "")
+
+	// Enroll in reverse order, listings are ordered by ID
+	mustEnroll(t, store, enrolled[2].Id, cloud.Id)
+	mustEnroll(t, store, enrolled[1].Id, cloud.Id)
+	mustEnroll(t, store, enrolled[0].Id, databases.Id)
+	mustEnroll(t, store, enrolled[0].Id, cloud.Id)
+
+	if got, want := studentCourses(t, store, enrolled[0].Id), []int32{cloud.Id, databases.Id}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ListStudentCourses(%d) = %v, want %v", enrolled[0].Id, got, want)
+	}
+	if got := studentCourses(t, store, enrolled[1].Id); fmt.Sprint(got) != fmt.Sprint([]int32{cloud.Id}) {
+		t.Errorf("ListStudentCourses(%d) = %v, want [%d]", enrolled[1].Id, got, cloud.Id)
+	}
+
+	if got := courseStudents(t, store, cloud.Id, 0, 0); fmt.Sprint(got) != fmt.Sprint(ids(enrolled)) {
+		t.Errorf("ListCourseStudents(%d) = %v, want %v", cloud.Id, got, ids(enrolled))
+	}
+	if got := courseStudents(t, store, cloud.Id, enrolled[0].Id, 1); fmt.Sprint(got) != fmt.Sprint([]int32{enrolled[1].Id}) {
+		t.Errorf("ListCourseStudents(%d, %d, 1) = %v, want [%d]", cloud.Id, enrolled[0].Id, got, enrolled[1].Id)
+	}
+
+	err := store.Enroll(context.Background(), enrolled[0].Id, cloud.Id)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("second Enroll(%d, %d) = %v, want ErrAlreadyExists", enrolled[0].Id, cloud.Id, err)
+	}
+}
+
+func testEnrollNotFound(t *testing.T, store storage.Store) {
+	enrolled := students("Ada Lovelace")
+	mustImport(t, store, enrolled)
+	course := mustCreateCourse(t, store, "Cloud Computing", "")
+
+	if err := store.Enroll(context.Background(), enrolled[0].Id+1, course.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Enroll() with unknown student = %v, want ErrNotFound", err)
+	}
+	if err := store.Enroll(context.Background(), enrolled[0].Id, course.Id+1); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Enroll() with unknown course = %v, want ErrNotFound", err)
+	}
+	if _, err := store.ListStudentCourses(context.Background(), enrolled[0].Id+1); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("ListStudentCourses() with unknown student = %v, want ErrNotFound", err)
+	}
+	if _, err := store.ListCourseStudents(context.Background(), course.Id+1, 0, 0); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("ListCourseStudents() with unknown course = %v, want ErrNotFound", err)
+	}
+
+	// Known but without enrollments
+	if got := studentCourses(t, store, enrolled[0].Id); len(got) != 0 {
+		t.Errorf("ListStudentCourses(%d) = %v, want none", enrolled[0].Id, got)
+	}
+	if got := courseStudents(t, store, course.Id, 0, 0); len(got) != 0 {
+		t.Errorf("ListCourseStudents(%d) = %v, want none", course.Id, got)
+	}
+}
+
+func testEnrollCapacity(t *testing.T, store storage.Store) {
+	enrolled := students("Ada Lovelace", "Alan Turing", "Grace Hopper")
+	mustImport(t, store, enrolled)
+
+	course := &api.Course{Name: "Seminar", Capacity: 2}
+	if err := store.CreateCourse(context.Background(), course); err != nil {
+		t.Fatalf("CreateCourse() = %v", err)
+	}
+	if got, err := store.GetCourse(context.Background(), course.Id); err != nil || got.Capacity != 2 {
+		t.Fatalf("GetCourse(%d) = %v, %v, want capacity 2", course.Id, got, err)
+	}
+
+	mustEnroll(t, store, enrolled[0].Id, course.Id)
+	mustEnroll(t, store, enrolled[1].Id, course.Id)
+	if err := store.Enroll(context.Background(), enrolled[2].Id, course.Id); !errors.Is(err, storage.ErrCourseFull) {
+		t.Fatalf("Enroll() in full course = %v, want ErrCourseFull", err)
+	}
+
+	// Leaving frees a seat
+	if err := store.Unenroll(context.Background(), enrolled[0].Id, course.Id); err != nil {
+		t.Fatalf("Unenroll() = %v", err)
+	}
+	mustEnroll(t, store, enrolled[2].Id, course.Id)
+}
+
+func testUnenroll(t *testing.T, store storage.Store) {
+	enrolled := students("Ada Lovelace")
+	mustImport(t, store, enrolled)
+	course := mustCreateCourse(t, store, "Cloud Computing", "")
+	mustEnroll(t, store, enrolled[0].Id, course.Id)
+
+	if err := store.Unenroll(context.Background(), enrolled[0].Id, course.Id); err != nil {
+		t.Fatalf("Unenroll() = %v", err)
+	}
+	if got := studentCourses(t, store, enrolled[0].Id); len(got) != 0 {
+		t.Errorf("ListStudentCourses(%d) after Unenroll() = %v, want none", enrolled[0].Id, got)
+	}
+	if err := store.Unenroll(context.Background(), enrolled[0].Id, course.Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("second Unenroll() = %v, want ErrNotFound", err)
+	}
+}
+
+func testDeleteRemovesEnrollments(t *testing.T, store storage.Store) {
+	enrolled := students("Ada Lovelace", "Alan Turing")
+	mustImport(t, store, enrolled)
+	cloud := mustCreateCourse(t, store, "Cloud Computing", "")
+	databases := mustCreateCourse(t, store, "Databases", "")
+	for _, student := range enrolled {
+		mustEnroll(t, store, student.Id, cloud.Id)
+		mustEnroll(t, store, student.Id, databases.Id)
+	}
+
+	if err := store.DeleteStudent(context.Background(), enrolled[0].Id); err != nil {
+		t.Fatalf("DeleteStudent() = %v", err)
+	}
+	if got := courseStudents(t, store, cloud.Id, 0, 0); fmt.Sprint(got) != fmt.Sprint([]int32{enrolled[1].Id}) {
+		t.Errorf("ListCourseStudents(%d) after deleting a student = %v, want [%d]", cloud.Id, got, enrolled[1].Id)
+	}
+
+	if err := store.DeleteCourse(context.Background(), databases.Id); err != nil {
+		t.Fatalf("DeleteCourse() = %v", err)
+	}
+	if got := studentCourses(t, store, enrolled[1].Id); fmt.Sprint(got) != fmt.Sprint([]int32{cloud.Id}) {
+		t.Errorf("ListStudentCourses(%d) after deleting a course = %v, want [%d]", enrolled[1].Id, got, cloud.Id)
+	}
+}
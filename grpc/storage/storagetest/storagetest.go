@@ -0,0 +1,401 @@
+// Package storagetest contains the conformance suite that every storage backend must pass.
+//
+// Backends call Run from their own tests:
+//
+//	func TestMemory(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.Store {
+//			return storage.NewMemory()
+//		})
+//	}
+//
+// Backends that persist their data to a file call RunPersistent instead.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/storage"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Factory returns a new, empty store. Run closes the store when the test finishes.
+type Factory func(t *testing.T) storage.Store
+
+// Opener opens the store persisted at path, creating it if necessary.
+type Opener func(t *testing.T, path string) storage.Store
+
+// Run runs the conformance suite against the stores returned by newStore.
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store storage.Store)
+	}{
+		{"GetStudentNotFound", testGetStudentNotFound},
+		{"ImportAssignsIDs", testImportAssignsIDs},
+		{"ImportReplacesIDs", testImportReplacesIDs},
+		{"ImportIDsIncrease", testImportIDsIncrease},
+		{"GetStudentReturnsCopy", testGetStudentReturnsCopy},
+		{"ListStudentsEmpty", testListStudentsEmpty},
+		{"ListStudentsOrdered", testListStudentsOrdered},
+		{"ListStudentsPages", testListStudentsPages},
+		{"ConcurrentImports", testConcurrentImports},
+		{"UpdateStudent", testUpdateStudent},
+		{"UpdateStudentNotFound", testUpdateStudentNotFound},
+		{"UpdateStudentAborted", testUpdateStudentAborted},
+		{"DeleteStudent", testDeleteStudent},
+		{"CreateCourse", testCreateCourse},
+		{"ListCourses", testListCourses},
+		{"UpdateCourse", testUpdateCourse},
+		{"DeleteCourse", testDeleteCourse},
+		{"Enroll", testEnroll},
+		{"EnrollNotFound", testEnrollNotFound},
+		{"EnrollCapacity", testEnrollCapacity},
+		{"Unenroll", testUnenroll},
+		{"DeleteRemovesEnrollments", testDeleteRemovesEnrollments},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore(t)
+			t.Cleanup(func() {
+				if err := store.Close(); err != nil {
+					t.Errorf("Close() = %v", err)
+				}
+			})
+			tt.test(t, store)
+		})
+	}
+}
+
+// RunPersistent runs Run and additionally checks that data and allocated IDs survive reopening the store.
+func RunPersistent(t *testing.T, open Opener) {
+	Run(t, func(t *testing.T) storage.Store {
+		return open(t, filepath.Join(t.TempDir(), "students.db"))
+	})
+
+	t.Run("Reopen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "students.db")
+
+		store := open(t, path)
+		imported := students("Ada Lovelace", "Alan Turing", "Grace Hopper")
+		mustImport(t, store, imported)
+		// The highest ID must not be reused either
+		if err := store.DeleteStudent(context.Background(), imported[2].Id); err != nil {
+			t.Fatalf("DeleteStudent(%d) = %v", imported[2].Id, err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close() = %v", err)
+		}
+
+		store = open(t, path)
+		defer store.Close()
+
+		got, err := store.GetStudent(context.Background(), imported[1].Id)
+		if err != nil {
+			t.Fatalf("GetStudent(%d) after reopen = %v", imported[1].Id, err)
+		}
+		if got.Name != "Alan Turing" {
+			t.Errorf("GetStudent(%d).Name after reopen = %q, want %q", imported[1].Id, got.Name, "Alan Turing")
+		}
+
+		next := students("Grace Hopper")
+		mustImport(t, store, next)
+		if next[0].Id <= imported[2].Id {
+			t.Errorf("ID %d allocated after reopen, want > %d", next[0].Id, imported[2].Id)
+		}
+	})
+}
+
+func students(names ...string) []*api.Student {
+	students := make([]*api.Student, len(names))
+	for i, name := range names {
+		students[i] = &api.Student{Name: name}
+	}
+	return students
+}
+
+func mustImport(t *testing.T, store storage.Store, students []*api.Student) {
+	t.Helper()
+	if err := store.ImportStudents(context.Background(), students); err != nil {
+		t.Fatalf("ImportStudents() = %v", err)
+	}
+}
+
+func mustList(t *testing.T, store storage.Store, afterID int32, limit int) []*api.Student {
+	t.Helper()
+	students, err := store.ListStudents(context.Background(), afterID, limit)
+	if err != nil {
+		t.Fatalf("ListStudents(%d, %d) = %v", afterID, limit, err)
+	}
+	return students
+}
+
+func ids(students []*api.Student) []int32 {
+	ids := make([]int32, len(students))
+	for i, student := range students {
+		ids[i] = student.Id
+	}
+	return ids
+}
+
+func testGetStudentNotFound(t *testing.T, store storage.Store) {
+	_, err := store.GetStudent(context.Background(), 42)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetStudent(42) = %v, want ErrNotFound", err)
+	}
+}
+
+func testImportAssignsIDs(t *testing.T, store storage.Store) {
+	imported := students("Ada Lovelace", "Alan Turing", "Grace Hopper")
+	mustImport(t, store, imported)
+
+	seen := make(map[int32]bool)
+	for i, student := range imported {
+		if student.Id <= 0 {
+			t.Fatalf("student %d has ID %d, want > 0", i, student.Id)
+		}
+		if seen[student.Id] {
+			t.Fatalf("ID %d assigned twice", student.Id)
+		}
+		seen[student.Id] = true
+
+		got, err := store.GetStudent(context.Background(), student.Id)
+		if err != nil {
+			t.Fatalf("GetStudent(%d) = %v", student.Id, err)
+		}
+		if got.Id != student.Id || got.Name != student.Name {
+			t.Errorf("GetStudent(%d) = %v, want %v", student.Id, got, student)
+		}
+	}
+}
+
+func testImportReplacesIDs(t *testing.T, store storage.Store) {
+	first := students("Ada Lovelace")
+	mustImport(t, store, first)
+
+	// IDs sent by clients must not overwrite existing students
+	second := []*api.Student{{Id: first[0].Id, Name: "Alan Turing"}}
+	mustImport(t, store, second)
+	if second[0].Id == first[0].Id {
+		t.Fatalf("ImportStudents() reused ID %d", first[0].Id)
+	}
+
+	got, err := store.GetStudent(context.Background(), first[0].Id)
+	if err != nil {
+		t.Fatalf("GetStudent(%d) = %v", first[0].Id, err)
+	}
+	if got.Name != "Ada Lovelace" {
+		t.Errorf("GetStudent(%d).Name = %q, want %q", first[0].Id, got.Name, "Ada Lovelace")
+	}
+}
+
+func testImportIDsIncrease(t *testing.T, store storage.Store) {
+	last := int32(0)
+	for i := 0; i < 3; i++ {
+		imported := students("A", "B")
+		mustImport(t, store, imported)
+
+		for _, student := range imported {
+			if student.Id <= last {
+				t.Fatalf("ID %d allocated after %d", student.Id, last)
+			}
+			last = student.Id
+		}
+	}
+}
+
+func testGetStudentReturnsCopy(t *testing.T, store storage.Store) {
+	imported := students("Ada Lovelace")
+	mustImport(t, store, imported)
+	id := imported[0].Id
+
+	// Modifying messages must not modify the stored data
+	imported[0].Name = "changed"
+	got, err := store.GetStudent(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStudent(%d) = %v", id, err)
+	}
+	got.Name = "changed"
+
+	got, err = store.GetStudent(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStudent(%d) = %v", id, err)
+	}
+	if got.Name != "Ada Lovelace" {
+		t.Errorf("GetStudent(%d).Name = %q, want %q", id, got.Name, "Ada Lovelace")
+	}
+}
+
+func testListStudentsEmpty(t *testing.T, store storage.Store) {
+	if got := mustList(t, store, 0, 10); len(got) != 0 {
+		t.Errorf("ListStudents() on empty store = %v, want none", got)
+	}
+}
+
+func testListStudentsOrdered(t *testing.T, store storage.Store) {
+	imported := students("A", "B", "C")
+	mustImport(t, store, imported)
+
+	got := mustList(t, store, 0, 0)
+	if fmt.Sprint(ids(got)) != fmt.Sprint(ids(imported)) {
+		t.Fatalf("ListStudents(0, 0) IDs = %v, want %v", ids(got), ids(imported))
+	}
+	for i := range got {
+		if got[i].Name != imported[i].Name {
+			t.Errorf("ListStudents(0, 0)[%d].Name = %q, want %q", i, got[i].Name, imported[i].Name)
+		}
+	}
+}
+
+func testListStudentsPages(t *testing.T, store storage.Store) {
+	mustImport(t, store, students("A", "B", "C", "D", "E"))
+
+	all := mustList(t, store, 0, 0)
+	if len(all) != 5 {
+		t.Fatalf("ListStudents(0, 0) returned %d students, want 5", len(all))
+	}
+
+	var paged []*api.Student
+	afterID := int32(0)
+	for {
+		page := mustList(t, store, afterID, 2)
+		if len(page) > 2 {
+			t.Fatalf("ListStudents(%d, 2) returned %d students", afterID, len(page))
+		}
+		if len(page) == 0 {
+			break
+		}
+		paged = append(paged, page...)
+		afterID = page[len(page)-1].Id
+	}
+
+	if fmt.Sprint(ids(paged)) != fmt.Sprint(ids(all)) {
+		t.Errorf("paged IDs = %v, want %v", ids(paged), ids(all))
+	}
+}
+
+func testConcurrentImports(t *testing.T, store storage.Store) {
+	const workers, perWorker = 8, 25
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				name := fmt.Sprintf("Student %d-%d", i, j)
+				if err := store.ImportStudents(context.Background(), students(name)); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("ImportStudents() = %v", err)
+	}
+
+	if got := mustList(t, store, 0, 0); len(got) != workers*perWorker {
+		t.Errorf("ListStudents() returned %d students, want %d", len(got), workers*perWorker)
+	}
+}
+
+func testUpdateStudent(t *testing.T, store storage.Store) {
+	imported := students("Ada Lovelace")
+	mustImport(t, store, imported)
+	id := imported[0].Id
+
+	updated, err := store.UpdateStudent(context.Background(), id, func(student *api.Student) error {
+		if student.Name != "Ada Lovelace" {
+			t.Errorf("update got Name = %q, want %q", student.Name, "Ada Lovelace")
+		}
+		student.Id = id + 100
+		student.Name = "Ada King"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateStudent(%d) = %v", id, err)
+	}
+	if updated.Id != id || updated.Name != "Ada King" {
+		t.Errorf("UpdateStudent(%d) = %v, want ID %d and name %q", id, updated, id, "Ada King")
+	}
+
+	got, err := store.GetStudent(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStudent(%d) = %v", id, err)
+	}
+	if got.Name != "Ada King" {
+		t.Errorf("GetStudent(%d).Name = %q, want %q", id, got.Name, "Ada King")
+	}
+
+	if _, err := store.GetStudent(context.Background(), id+100); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudent(%d) = %v, want ErrNotFound", id+100, err)
+	}
+}
+
+func testUpdateStudentNotFound(t *testing.T, store storage.Store) {
+	_, err := store.UpdateStudent(context.Background(), 42, func(student *api.Student) error {
+		t.Error("update called for missing student")
+		return nil
+	})
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("UpdateStudent(42) = %v, want ErrNotFound", err)
+	}
+}
+
+func testUpdateStudentAborted(t *testing.T, store storage.Store) {
+	imported := students("Ada Lovelace")
+	mustImport(t, store, imported)
+	id := imported[0].Id
+
+	abort := errors.New("abort")
+	_, err := store.UpdateStudent(context.Background(), id, func(student *api.Student) error {
+		student.Name = "changed"
+		return abort
+	})
+	if !errors.Is(err, abort) {
+		t.Fatalf("UpdateStudent(%d) = %v, want %v", id, err, abort)
+	}
+
+	got, err := store.GetStudent(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStudent(%d) = %v", id, err)
+	}
+	if got.Name != "Ada Lovelace" {
+		t.Errorf("GetStudent(%d).Name = %q after aborted update, want %q", id, got.Name, "Ada Lovelace")
+	}
+}
+
+func testDeleteStudent(t *testing.T, store storage.Store) {
+	imported := students("Ada Lovelace", "Alan Turing")
+	mustImport(t, store, imported)
+
+	if err := store.DeleteStudent(context.Background(), imported[1].Id); err != nil {
+		t.Fatalf("DeleteStudent(%d) = %v", imported[1].Id, err)
+	}
+	if _, err := store.GetStudent(context.Background(), imported[1].Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetStudent(%d) after delete = %v, want ErrNotFound", imported[1].Id, err)
+	}
+	if err := store.DeleteStudent(context.Background(), imported[1].Id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("second DeleteStudent(%d) = %v, want ErrNotFound", imported[1].Id, err)
+	}
+
+	if got := mustList(t, store, 0, 0); fmt.Sprint(ids(got)) != fmt.Sprint([]int32{imported[0].Id}) {
+		t.Errorf("ListStudents() IDs after delete = %v, want [%d]", ids(got), imported[0].Id)
+	}
+
+	// Deleted IDs are not reused
+	next := students("Grace Hopper")
+	mustImport(t, store, next)
+	if next[0].Id <= imported[1].Id {
+		t.Errorf("ID %d allocated after deleting %d", next[0].Id, imported[1].Id)
+	}
+}
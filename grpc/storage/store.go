@@ -0,0 +1,93 @@
+// Package storage contains the backends that hold the students served by the gRPC server.
+//
+// All backends implement Store and must pass the conformance suite in package storagetest.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/simonhammes/301-cloud-computing-project/grpc/api"
+)
+
+var (
+	// ErrNotFound is returned when a requested student, course or enrollment does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists is returned when a student is already enrolled in a course.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrCourseFull is returned when a course has reached its capacity.
+	ErrCourseFull = errors.New("course is full")
+)
+
+// Store is implemented by all storage backends. Implementations must be safe for concurrent use.
+type Store interface {
+	StudentStore
+	CourseStore
+	EnrollmentStore
+	// Close releases all resources held by the backend.
+	Close() error
+}
+
+// StudentStore holds students.
+type StudentStore interface {
+	// GetStudent returns the student with the given ID or ErrNotFound.
+	GetStudent(ctx context.Context, id int32) (*api.Student, error)
+	// ListStudents returns up to limit students with an ID greater than afterID, ordered by ID.
+	// A limit <= 0 returns all remaining students.
+	ListStudents(ctx context.Context, afterID int32, limit int) ([]*api.Student, error)
+	// ImportStudents stores the given students atomically.
+	// Every student is assigned a new ID, which is written back to the passed message.
+	// IDs increase monotonically and are never reused, not even after a restart of persistent backends.
+	ImportStudents(ctx context.Context, students []*api.Student) error
+	// UpdateStudent atomically applies update to a copy of the student with the given ID,
+	// stores the result and returns it. It returns ErrNotFound if the student does not exist
+	// and any error returned by update. The ID cannot be changed.
+	UpdateStudent(ctx context.Context, id int32, update func(student *api.Student) error) (*api.Student, error)
+	// DeleteStudent deletes the student with the given ID and its enrollments or returns ErrNotFound.
+	DeleteStudent(ctx context.Context, id int32) error
+}
+
+// CourseStore holds courses. Its methods behave like their StudentStore counterparts,
+// course IDs are allocated independently of student IDs.
+// Deleting a course deletes its enrollments.
+type CourseStore interface {
+	GetCourse(ctx context.Context, id int32) (*api.Course, error)
+	ListCourses(ctx context.Context, afterID int32, limit int) ([]*api.Course, error)
+	// CreateCourse stores the course and writes the assigned ID back to the passed message.
+	CreateCourse(ctx context.Context, course *api.Course) error
+	UpdateCourse(ctx context.Context, id int32, update func(course *api.Course) error) (*api.Course, error)
+	DeleteCourse(ctx context.Context, id int32) error
+}
+
+// EnrollmentStore holds the relationship between students and courses.
+type EnrollmentStore interface {
+	// Enroll enrolls a student in a course. It returns ErrNotFound if either does not exist,
+	// ErrAlreadyExists if the student is already enrolled and ErrCourseFull if the course has
+	// reached its capacity. Lowering the capacity later does not affect existing enrollments.
+	Enroll(ctx context.Context, studentID, courseID int32) error
+	// Unenroll removes an enrollment or returns ErrNotFound.
+	Unenroll(ctx context.Context, studentID, courseID int32) error
+	// ListStudentCourses returns the courses of a student ordered by ID or ErrNotFound if the student does not exist.
+	ListStudentCourses(ctx context.Context, studentID int32) ([]*api.Course, error)
+	// ListCourseStudents returns up to limit students of a course with an ID greater than afterID, ordered by ID.
+	// It returns ErrNotFound if the course does not exist.
+	ListCourseStudents(ctx context.Context, courseID, afterID int32, limit int) ([]*api.Student, error)
+}
+
+// Backends lists the names accepted by Open.
+var Backends = []string{"memory", "sqlite", "bolt"}
+
+// Open returns the backend with the given name.
+// path is the database file and ignored by the memory backend.
+func Open(backend, path string) (Store, error) {
+	switch backend {
+	case "memory":
+		return NewMemory(), nil
+	case "sqlite":
+		return OpenSQLite(path)
+	case "bolt":
+		return OpenBolt(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}